@@ -0,0 +1,223 @@
+package ParserCore
+
+// Amounts.
+// PARSE_MONEY, PARSE_PERCENTAGE and PARSE_QUANTITY read a value which is written as more than one
+// token, such as "$150.25" (a DOLLAR and a FLOAT), "5%" (an INTEGER and a PERCENT) or "2.5M"
+// (a FLOAT and the word M), and hand the handler a single typed value.
+//
+// Currencies and multiplier suffixes come from an AmountTable, so other currencies and
+// suffixes can be added.  DefaultAmounts is used if the step does not name a table.
+// A multiplier suffix must be written against its number, so "10k" is ten thousand but "10 k" is not.
+// A currency can be written before or after the amount, with or without a space, so "USD100",
+// which the lexer reads as a single word, is an amount too.
+
+import (
+	"strings"
+)
+
+// AmountTable holds the currencies and multiplier suffixes used to read amounts.
+type AmountTable struct {
+	Currencies  map[string]string  // Currency symbols and codes, such as "$" and "USD", and the code they stand for
+	Multipliers map[string]float64 // Suffixes which multiply a number, such as the k of 10k
+}
+
+// DefaultAmounts is the AmountTable used when a step does not give one.
+var DefaultAmounts = &AmountTable{
+	Currencies: map[string]string{
+		"$": "USD", "USD": "USD",
+		"€": "EUR", "EUR": "EUR",
+		"£": "GBP", "GBP": "GBP",
+		"¥": "JPY", "JPY": "JPY",
+		"CHF": "CHF", "CAD": "CAD", "AUD": "AUD",
+	},
+	Multipliers: map[string]float64{
+		"k": 1e3, "K": 1e3,
+		"M": 1e6, "MM": 1e6,
+		"B": 1e9, "bn": 1e9,
+		"T": 1e12,
+	},
+}
+
+// Money is the value of a PARSE_MONEY step.
+type Money struct {
+	Amount     float64 // The amount, with any multiplier applied
+	Currency   string  // The currency code, such as USD
+	Multiplier float64 // The multiplier of a suffix such as M, or 1
+	Text       string  // The amount as it was written
+}
+
+// Percentage is the value of a PARSE_PERCENTAGE step.
+type Percentage struct {
+	Value float64 // The percentage as written, so 5 for 5%
+	Text  string  // The percentage as it was written
+}
+
+// Fraction returns the percentage as a fraction, so 0.05 for 5%.
+func (p Percentage) Fraction() float64 {
+	return p.Value / 100
+}
+
+// Quantity is the value of a PARSE_QUANTITY step.
+type Quantity struct {
+	Value      float64 // The quantity, with any multiplier applied
+	Multiplier float64 // The multiplier of a suffix such as k, or 1
+	Suffix     string  // The suffix, such as k, or "" if there was none
+	Text       string  // The quantity as it was written
+}
+
+// inputText returns the input from the start of one token to the end of another.
+func inputText(l *Lexer, first Token, last Token) string {
+	return l.input[first.Offset : last.Offset+len(last.Raw)]
+}
+
+// currency returns the code of the currency a token stands for.  Codes are matched in upper case.
+func (t *AmountTable) currency(tok Token) (string, bool) {
+	if tok.Type == QUOTED_STRING || tok.Type == EOF {
+		return "", false
+	}
+	if code, ok := t.Currencies[tok.Raw]; ok {
+		return code, true
+	}
+	code, ok := t.Currencies[strings.ToUpper(tok.Raw)]
+	return code, ok
+}
+
+// parseNumber reads an integer or a float as a float64.
+func parseNumber(l *Lexer) (error, float64, Token) {
+	start := l.Checkpoint()
+	tok := l.NextToken()
+	l.Restore(start)
+	switch tok.Type {
+	case INTEGER:
+		err, value := parseAnyInteger(l, 0)
+		return err, float64(value), tok
+	case FLOAT:
+		err, value := parseAnyFloat(l, 0)
+		return err, value, tok
+	}
+	return newParseError(tok, TokenTypeNames[INTEGER], TokenTypeNames[FLOAT]), 0, tok
+}
+
+// parseMultiplied reads a number with an optional multiplier suffix, returning the last token read.
+func parseMultiplied(l *Lexer, table *AmountTable) (error, Quantity, Token) {
+	err, value, first := parseNumber(l)
+	if err != nil {
+		return err, Quantity{}, first
+	}
+	quantity := Quantity{Value: value, Multiplier: 1, Text: first.Raw}
+	end := l.Checkpoint()
+	if next := l.NextToken(); next.Type == STRING && adjacent(first, next) {
+		if multiplier, ok := table.Multipliers[next.Value]; ok {
+			quantity.Value *= multiplier
+			quantity.Multiplier = multiplier
+			quantity.Suffix = next.Value
+			quantity.Text = inputText(l, first, next)
+			return nil, quantity, next
+		}
+	}
+	l.Restore(end)
+	return nil, quantity, first
+}
+
+// parseQuantity reads a number with an optional multiplier suffix, such as 100, 10k or 2.5M.
+func parseQuantity(l *Lexer, table *AmountTable) (error, Quantity) {
+	if table == nil {
+		table = DefaultAmounts
+	}
+	err, quantity, _ := parseMultiplied(l, table)
+	return err, quantity
+}
+
+// joinedCurrency reads an amount written against a currency code, as in USD100 or USD1.5M, which
+// the lexer reads as a word followed by the rest of the number.  The amount is read from the input
+// after the code, and must end at the end of a token.  The word has already been read.
+func (t *AmountTable) joinedCurrency(l *Lexer, first Token) (Money, bool) {
+	n := strings.IndexAny(first.Raw, "0123456789")
+	if first.Type != STRING || n <= 0 {
+		return Money{}, false
+	}
+	code, ok := t.currency(Token{Type: STRING, Raw: first.Raw[:n]})
+	if !ok {
+		return Money{}, false
+	}
+	// The amount can run on into the tokens after the word, as the point, 5 and M of USD1.5M do
+	start := l.Checkpoint()
+	ends, marks := []int{first.Offset + len(first.Raw)}, []int{start}
+	for last := first; ; {
+		tok := l.NextToken()
+		if tok.Type == EOF || !adjacent(last, tok) {
+			break
+		}
+		ends, marks = append(ends, tok.Offset+len(tok.Raw)), append(marks, l.Checkpoint())
+		last = tok
+	}
+
+	amount := &Lexer{input: l.input[:ends[len(ends)-1]], pos: first.Offset + n, line: first.Line,
+		column: first.Column + n, punctuation: l.punctuation, quotes: l.quotes, numbers: l.numbers}
+	err, quantity, end := parseMultiplied(amount, t)
+	if err != nil {
+		l.Restore(start)
+		return Money{}, false
+	}
+	for i, offset := range ends {
+		if offset == end.Offset+len(end.Raw) {
+			l.Restore(marks[i])
+			return Money{Amount: quantity.Value, Currency: code, Multiplier: quantity.Multiplier,
+				Text: l.input[first.Offset:offset]}, true
+		}
+	}
+	l.Restore(start)
+	return Money{}, false
+}
+
+// parseMoney reads an amount of money, such as $150.25, USD 100, USD100, 100 USD or €2.5M.
+func parseMoney(l *Lexer, table *AmountTable) (error, Money) {
+	if table == nil {
+		table = DefaultAmounts
+	}
+	start := l.Checkpoint()
+	first := l.NextToken()
+	if money, ok := table.joinedCurrency(l, first); ok {
+		return nil, money
+	}
+	if code, ok := table.currency(first); ok {
+		// The currency comes first
+		err, quantity, last := parseMultiplied(l, table)
+		if err != nil {
+			l.Restore(start)
+			return err, Money{}
+		}
+		return nil, Money{Amount: quantity.Value, Currency: code, Multiplier: quantity.Multiplier, Text: inputText(l, first, last)}
+	}
+	l.Restore(start)
+
+	err, quantity, _ := parseMultiplied(l, table)
+	if err != nil {
+		if perr, ok := err.(*ParseError); ok && perr.Message == "" {
+			perr.Expected = []string{"currency", "amount"}
+		}
+		return err, Money{}
+	}
+	tok := l.NextToken()
+	code, ok := table.currency(tok)
+	if !ok {
+		l.Restore(start)
+		return newParseError(tok, "currency"), Money{}
+	}
+	return nil, Money{Amount: quantity.Value, Currency: code, Multiplier: quantity.Multiplier, Text: inputText(l, first, tok)}
+}
+
+// parsePercentage reads a number followed by a percent sign, such as 5% or 2.5 %.
+func parsePercentage(l *Lexer) (error, Percentage) {
+	start := l.Checkpoint()
+	err, value, first := parseNumber(l)
+	if err != nil {
+		return err, Percentage{}
+	}
+	tok := l.NextToken()
+	if tok.Type != PERCENT {
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[PERCENT]), Percentage{}
+	}
+	return nil, Percentage{Value: value, Text: inputText(l, first, tok)}
+}
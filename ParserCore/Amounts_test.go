@@ -0,0 +1,134 @@
+package ParserCore
+
+import (
+	"testing"
+)
+
+func Test_parseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Money
+	}{
+		{"$150.25", Money{Amount: 150.25, Currency: "USD", Multiplier: 1, Text: "$150.25"}},
+		{"USD 100", Money{Amount: 100, Currency: "USD", Multiplier: 1, Text: "USD 100"}},
+		{"usd 100", Money{Amount: 100, Currency: "USD", Multiplier: 1, Text: "usd 100"}},
+		{"100 EUR", Money{Amount: 100, Currency: "EUR", Multiplier: 1, Text: "100 EUR"}},
+		{"€2.5M", Money{Amount: 2500000, Currency: "EUR", Multiplier: 1e6, Text: "€2.5M"}},
+		{"15£", Money{Amount: 15, Currency: "GBP", Multiplier: 1, Text: "15£"}},
+		{"$1,000 each", Money{Amount: 1000, Currency: "USD", Multiplier: 1, Text: "$1,000"}},
+		{"USD100", Money{Amount: 100, Currency: "USD", Multiplier: 1, Text: "USD100"}},
+		{"usd1.5M", Money{Amount: 1500000, Currency: "USD", Multiplier: 1e6, Text: "usd1.5M"}},
+		{"EUR2,500 now", Money{Amount: 2500, Currency: "EUR", Multiplier: 1, Text: "EUR2,500"}},
+		{"100USD", Money{Amount: 100, Currency: "USD", Multiplier: 1, Text: "100USD"}},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseMoney(l, nil)
+		if err != nil || value != test.expected {
+			t.Errorf("parseMoney(%s) failed, expected %+v, got %+v with error '%v'", test.input, test.expected, value, err)
+		}
+	}
+
+	for _, input := range []string{"100", "$ten", "USD", "100 shares", "USD100x", "ABC100"} {
+		l := NewLexer(input, nil)
+		if err, _ := parseMoney(l, nil); err == nil {
+			t.Errorf("parseMoney(%s) failed, expected an error", input)
+		}
+		if l.Checkpoint() != 0 {
+			t.Errorf("parseMoney(%s) failed, expected no tokens read, got %d", input, l.Checkpoint())
+		}
+	}
+
+	table := &AmountTable{Currencies: map[string]string{"BTC": "BTC"}}
+	l := NewLexer("0.5 btc", nil)
+	err, value := parseMoney(l, table)
+	if err != nil || value.Currency != "BTC" || value.Amount != 0.5 {
+		t.Errorf("parseMoney(0.5 btc) failed, expected 0.5 BTC, got %+v with error '%v'", value, err)
+	}
+}
+
+func Test_parsePercentage(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Percentage
+	}{
+		{"5%", Percentage{Value: 5, Text: "5%"}},
+		{"2.5 %", Percentage{Value: 2.5, Text: "2.5 %"}},
+		{"-10%", Percentage{Value: -10, Text: "-10%"}},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parsePercentage(l)
+		if err != nil || value != test.expected {
+			t.Errorf("parsePercentage(%s) failed, expected %+v, got %+v with error '%v'", test.input, test.expected, value, err)
+		}
+	}
+	if fraction := (Percentage{Value: 5}).Fraction(); fraction != 0.05 {
+		t.Errorf("Fraction() failed, expected 0.05, got %v", fraction)
+	}
+
+	l := NewLexer("5 shares", nil)
+	if err, _ := parsePercentage(l); err == nil || l.Checkpoint() != 0 {
+		t.Errorf("parsePercentage(5 shares) failed, expected an error with no tokens read, got '%v'", err)
+	}
+}
+
+func Test_parseQuantity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Quantity
+		next     string
+	}{
+		{"100", Quantity{Value: 100, Multiplier: 1, Text: "100"}, ""},
+		{"10k", Quantity{Value: 10000, Multiplier: 1e3, Suffix: "k", Text: "10k"}, ""},
+		{"2.5M shares", Quantity{Value: 2500000, Multiplier: 1e6, Suffix: "M", Text: "2.5M"}, "shares"},
+		{"3bn", Quantity{Value: 3e9, Multiplier: 1e9, Suffix: "bn", Text: "3bn"}, ""},
+		{"10 k", Quantity{Value: 10, Multiplier: 1, Text: "10"}, "k"},
+		{"10x", Quantity{Value: 10, Multiplier: 1, Text: "10"}, "x"},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseQuantity(l, nil)
+		if err != nil || value != test.expected {
+			t.Errorf("parseQuantity(%s) failed, expected %+v, got %+v with error '%v'", test.input, test.expected, value, err)
+			continue
+		}
+		if tok := l.NextToken(); tok.Raw != test.next {
+			t.Errorf("parseQuantity(%s) failed, expected '%s' next, got '%s'", test.input, test.next, tok.Raw)
+		}
+	}
+}
+
+func TestParserObject_Amounts(t *testing.T) {
+	type order struct {
+		price Money
+		stop  Percentage
+	}
+	rules := []ParseRule{
+		{
+			Name: "Order",
+			Steps: []ParserRuleStep{
+				{Name: "At", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"AT"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "Price", ParserType: PARSE_MONEY, SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*order).price = token.(Money)
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+				{Name: "Stop", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"STOP"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_FAILURE},
+				{Name: "StopAt", ParserType: PARSE_PERCENTAGE, SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*order).stop = token.(Percentage)
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+			},
+		},
+	}
+	var o order
+	p := ParserObject{Input: "at $150.25 stop 5%"}
+	parse, err := p.Parse(rules, &o)
+	if parse != PARSE_RESULT_SUCCESS || o.price.Amount != 150.25 || o.stop.Value != 5 {
+		t.Errorf("Parse() failed, expected $150.25 and 5%%, got %d %+v with error '%v'", parse, o, err)
+	}
+}
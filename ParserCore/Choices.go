@@ -0,0 +1,98 @@
+package ParserCore
+
+// Mapped choices.
+// Many steps only turn a word into a value: YES or ON into true, or PURCHASE and ACQUIRE into BUY.
+// A PARSE_MAPPED_CHOICE step reads one of the words in its MappedValues and passes the handler the
+// value the word stands for.  A word can be several words long, such as "BUY BACK", and the longest
+// matching word wins.  A PARSE_BOOLEAN step is the same with the words in BooleanWords.
+
+import (
+	"sort"
+	"strings"
+)
+
+// BooleanWords are the words a PARSE_BOOLEAN step reads, in upper case, and the values they stand for.
+var BooleanWords = map[string]bool{
+	"TRUE": true, "YES": true, "Y": true, "ON": true, "ENABLE": true, "ENABLED": true,
+	"FALSE": false, "NO": false, "N": false, "OFF": false, "DISABLE": false, "DISABLED": false,
+}
+
+// Mapped converts a map of values of a single type into the map a PARSE_MAPPED_CHOICE step takes.
+func Mapped[V any](values map[string]V) map[string]interface{} {
+	mapped := make(map[string]interface{}, len(values))
+	for word, value := range values {
+		mapped[word] = value
+	}
+	return mapped
+}
+
+// choiceWords returns the words of a map, longest first and otherwise in alphabetical order,
+// so that the words are always tried in the same order.
+func choiceWords[V any](values map[string]V) []string {
+	words := make([]string, 0, len(values))
+	for word := range values {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		ni, nj := len(strings.Fields(words[i])), len(strings.Fields(words[j]))
+		if ni != nj {
+			return ni > nj
+		}
+		return words[i] < words[j]
+	})
+	return words
+}
+
+// firstWords returns the first word of each word in a map, for indexing rules.
+func firstWords[V any](values map[string]V) []string {
+	var first []string
+	for _, word := range choiceWords(values) {
+		if fields := strings.Fields(word); len(fields) > 0 {
+			first = mergeExpected(first, fields[:1])
+		}
+	}
+	sort.Strings(first)
+	return first
+}
+
+// readWords reads the words of a phrase in turn, converting each token with the options given.
+func readWords(l *Lexer, phrase string, opt int) bool {
+	start := l.Checkpoint()
+	for _, word := range strings.Fields(phrase) {
+		tok := l.NextToken()
+		if tok.Type != STRING || convertString(tok.Value, opt) != word {
+			l.Restore(start)
+			return false
+		}
+	}
+	return true
+}
+
+// parseMappedChoice reads one of the words of a map and returns the value it stands for.
+// Words are compared after converting them with the options, as for PARSE_STRING_CHOICE.
+func parseMappedChoice(l *Lexer, values map[string]interface{}, opt int) (error, interface{}) {
+	words := choiceWords(values)
+	for _, word := range words {
+		if readWords(l, word, opt) {
+			return nil, values[word]
+		}
+	}
+	start := l.Checkpoint()
+	tok := l.NextToken()
+	l.Restore(start)
+	sort.Strings(words)
+	return newParseError(tok, words...), nil
+}
+
+// parseBoolean reads one of the BooleanWords, in any case.
+func parseBoolean(l *Lexer) (error, bool) {
+	for _, word := range choiceWords(BooleanWords) {
+		if readWords(l, word, PARSE_OPTION_CONVERT_TO_UPPERCASE) {
+			return nil, BooleanWords[word]
+		}
+	}
+	start := l.Checkpoint()
+	tok := l.NextToken()
+	l.Restore(start)
+	return newParseError(tok, "boolean"), false
+}
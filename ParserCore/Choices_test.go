@@ -0,0 +1,114 @@
+package ParserCore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseBoolean(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"yes", true},
+		{"ON", true},
+		{"True", true},
+		{"no", false},
+		{"off", false},
+		{"Disabled", false},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseBoolean(l)
+		if err != nil || value != test.expected {
+			t.Errorf("parseBoolean(%s) failed, expected %v, got %v with error '%v'", test.input, test.expected, value, err)
+		}
+	}
+	l := NewLexer("maybe", nil)
+	if err, _ := parseBoolean(l); err == nil || l.Checkpoint() != 0 {
+		t.Errorf("parseBoolean(maybe) failed, expected an error with no tokens read, got '%v'", err)
+	}
+}
+
+func Test_parseMappedChoice(t *testing.T) {
+	values := map[string]interface{}{
+		"BUY":      "BUY",
+		"PURCHASE": "BUY",
+		"BUY BACK": "BUYBACK",
+		"SELL":     "SELL",
+		"HALF":     0.5,
+	}
+	tests := []struct {
+		input    string
+		expected interface{}
+		next     string
+	}{
+		{"purchase 10", "BUY", "10"},
+		{"buy 10", "BUY", "10"},
+		{"buy back 10", "BUYBACK", "10"},
+		{"buy backwards", "BUY", "backwards"},
+		{"half", 0.5, ""},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseMappedChoice(l, values, PARSE_OPTION_CONVERT_TO_UPPERCASE)
+		if err != nil || value != test.expected {
+			t.Errorf("parseMappedChoice(%s) failed, expected %v, got %v with error '%v'", test.input, test.expected, value, err)
+			continue
+		}
+		if tok := l.NextToken(); tok.Raw != test.next {
+			t.Errorf("parseMappedChoice(%s) failed, expected '%s' next, got '%s'", test.input, test.next, tok.Raw)
+		}
+	}
+
+	// Without CONVERT_TO_UPPERCASE the words must match exactly
+	l := NewLexer("buy", nil)
+	err, _ := parseMappedChoice(l, values, 0)
+	perr, ok := err.(*ParseError)
+	expected := []string{"BUY", "BUY BACK", "HALF", "PURCHASE", "SELL"}
+	if !ok || !reflect.DeepEqual(perr.Expected, expected) || l.Checkpoint() != 0 {
+		t.Errorf("parseMappedChoice(buy) failed, expected an error expecting %v, got '%v'", expected, err)
+	}
+}
+
+func TestParserObject_MappedChoice(t *testing.T) {
+	type order struct {
+		Side    int
+		Confirm bool
+	}
+	const (
+		buy = iota + 1
+		sell
+	)
+	rules := []TypedRule[order]{
+		{
+			Name: "Order",
+			Steps: []TypedStep[order]{
+				Step[order, int]{
+					ParserRuleStep: ParserRuleStep{Name: "Side", ParserType: PARSE_MAPPED_CHOICE,
+						MappedValues: Mapped(map[string]int{"BUY": buy, "ACQUIRE": buy, "SELL": sell}),
+						Options:      PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+					Handler: Bind(func(o *order) *int { return &o.Side }),
+				},
+				Step[order, bool]{
+					ParserRuleStep: ParserRuleStep{Name: "Confirm", ParserType: PARSE_BOOLEAN, SkipOnError: PARSE_RESULT_FAILURE},
+					Handler:        Bind(func(o *order) *bool { return &o.Confirm }),
+				},
+			},
+		},
+	}
+	var o order
+	p := ParserObject{Input: "acquire yes"}
+	parse, err := ParseTyped(&p, rules, &o)
+	if parse != PARSE_RESULT_SUCCESS || o.Side != buy || !o.Confirm {
+		t.Errorf("ParseTyped() failed, expected {%d true}, got %d %+v with error '%v'", buy, parse, o, err)
+	}
+
+	rs := NewRuleSet([]ParseRule{rules[0].Untyped()})
+	if got := len(rs.candidates(Token{Type: STRING, Value: "acquire"})); got != 1 {
+		t.Errorf("NewRuleSet() failed, expected the rule indexed under ACQUIRE, got %d candidates", got)
+	}
+	if got := len(rs.candidates(Token{Type: STRING, Value: "hold"})); got != 0 {
+		t.Errorf("NewRuleSet() failed, expected no rules for HOLD, got %d candidates", got)
+	}
+}
@@ -0,0 +1,294 @@
+package ParserCore
+
+// Combinators let grammars be built by nesting small parsers inside larger ones,
+// rather than by writing out flat lists of ParserRuleStep.
+//
+// A Combinator reads tokens from a Lexer and returns the value it recognised.
+// If a combinator fails it returns an error and leaves the lexer where it found it,
+// so the caller is free to try something else from the same position.
+// The exception is a rule that fails outright (PARSE_RESULT_FAILURE): alternatives are
+// not tried after that, and the failure is passed all the way up.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Combinator is a parser function that runs against a Lexer.
+type Combinator func(l *Lexer) (interface{}, error)
+
+// Expect matches a single token of the given type and returns its text.
+func Expect(tokType TokenType) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		start := l.Checkpoint()
+		tok := l.NextToken()
+		if tok.Type != tokType {
+			l.Restore(start)
+			return nil, newParseError(tok, tokenTypeName(tokType))
+		}
+		return tok.Value, nil
+	}
+}
+
+// Keyword matches a single STRING token against a list of words, ignoring case.
+// It returns the word from the list that matched.
+func Keyword(words ...string) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		start := l.Checkpoint()
+		tok := l.NextToken()
+		if tok.Type == STRING {
+			for _, word := range words {
+				if strings.EqualFold(tok.Value, word) {
+					return word, nil
+				}
+			}
+		}
+		l.Restore(start)
+		return nil, newParseError(tok, words...)
+	}
+}
+
+// Seq runs each parser in turn and returns their values as a []interface{}.
+// If any parser fails, the whole sequence fails and nothing is consumed.
+func Seq(parsers ...Combinator) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		start := l.Checkpoint()
+		values := make([]interface{}, 0, len(parsers))
+		for _, parser := range parsers {
+			value, err := parser(l)
+			if err != nil {
+				l.Restore(start)
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+}
+
+// Alt tries each parser in turn from the same position and returns the value of the first that succeeds.
+// If none succeed, the error is from whichever alternative got furthest.
+func Alt(parsers ...Combinator) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		start := l.Checkpoint()
+		err := fmt.Errorf("no alternatives to try")
+		var furthest *ParseError
+		for _, parser := range parsers {
+			var value interface{}
+			value, err = parser(l)
+			if err == nil {
+				return value, nil
+			}
+			l.Restore(start)
+			if isFailure(err) {
+				return nil, err
+			}
+			furthest = furthestError(furthest, err)
+		}
+		if furthest != nil {
+			return nil, furthest
+		}
+		return nil, err
+	}
+}
+
+// Optional runs the parser and returns def instead of failing if it does not match.
+func Optional(parser Combinator, def interface{}) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		start := l.Checkpoint()
+		value, err := parser(l)
+		if err != nil {
+			l.Restore(start)
+			if isFailure(err) {
+				return nil, err
+			}
+			return def, nil
+		}
+		return value, nil
+	}
+}
+
+// Many runs the parser zero or more times and returns the values as a []interface{}.
+func Many(parser Combinator) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		values := []interface{}{}
+		for {
+			start := l.Checkpoint()
+			value, err := parser(l)
+			if err != nil {
+				l.Restore(start)
+				if isFailure(err) {
+					return nil, err
+				}
+				return values, nil
+			}
+			values = append(values, value)
+			// A parser that matches without reading anything would loop forever
+			if l.Checkpoint() == start {
+				return values, nil
+			}
+		}
+	}
+}
+
+// Many1 runs the parser one or more times and returns the values as a []interface{}.
+func Many1(parser Combinator) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		first, err := parser(l)
+		if err != nil {
+			return nil, err
+		}
+		rest, err := Many(parser)(l)
+		if err != nil {
+			return nil, err
+		}
+		return append([]interface{}{first}, rest.([]interface{})...), nil
+	}
+}
+
+// SepBy matches zero or more occurrences of parser separated by sep.
+// The values of parser are returned as a []interface{}, the separators are discarded.
+func SepBy(parser Combinator, sep Combinator) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		values, err := SepBy1(parser, sep)(l)
+		if err != nil {
+			if isFailure(err) {
+				return nil, err
+			}
+			return []interface{}{}, nil
+		}
+		return values, nil
+	}
+}
+
+// SepBy1 is like SepBy but requires at least one occurrence of parser.
+func SepBy1(parser Combinator, sep Combinator) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		first, err := parser(l)
+		if err != nil {
+			return nil, err
+		}
+		rest, err := Many(Seq(sep, parser))(l)
+		if err != nil {
+			return nil, err
+		}
+		values := []interface{}{first}
+		for _, pair := range rest.([]interface{}) {
+			values = append(values, pair.([]interface{})[1])
+		}
+		return values, nil
+	}
+}
+
+// Between matches open, parser and close in order and returns the value of parser.
+func Between(open Combinator, parser Combinator, close Combinator) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		values, err := Seq(open, parser, close)(l)
+		if err != nil {
+			return nil, err
+		}
+		return values.([]interface{})[1], nil
+	}
+}
+
+// Map runs the parser and passes its value through f.
+// If f returns an error the match fails and nothing is consumed.
+func Map(parser Combinator, f func(value interface{}) (interface{}, error)) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		start := l.Checkpoint()
+		value, err := parser(l)
+		if err != nil {
+			return nil, err
+		}
+		value, err = f(value)
+		if err != nil {
+			l.Restore(start)
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// StepCombinator compiles a single ParserRuleStep into a Combinator.
+// The combinator returns the same value the step would pass to its ParseHandler,
+// which lets existing step definitions be used as pieces of a combinator grammar.
+// A PARSE_SUBRULE step runs its rules with a nil data object; use RuleCombinator
+// if the rules need one.
+func (p *ParserObject) StepCombinator(step ParserRuleStep) Combinator {
+	var data interface{}
+	return p.stepCombinator(step, &data)
+}
+
+// stepCombinator compiles a step into a Combinator which runs any sub-rules against data.
+func (p *ParserObject) stepCombinator(step ParserRuleStep, data *interface{}) Combinator {
+	var parser Combinator = func(l *Lexer) (interface{}, error) {
+		start := l.Checkpoint()
+		err, value := p.parseStepValue(l, step, data)
+		if err != nil {
+			l.Restore(start)
+			return nil, err
+		}
+		return value, nil
+	}
+	if step.MaxCount != 0 {
+		parser = repeat(parser, step.MinCount, step.MaxCount)
+	}
+	if step.Options&PARSE_OPTION_OPTIONAL != 0 {
+		parser = Optional(parser, step.Default)
+	}
+	return parser
+}
+
+// repeat matches parser at least min times and at most max times, or without limit if max is negative.
+// The values are returned as a []interface{}.
+func repeat(parser Combinator, min int, max int) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		start := l.Checkpoint()
+		values := []interface{}{}
+		for max < 0 || len(values) < max {
+			before := l.Checkpoint()
+			value, err := parser(l)
+			if err != nil {
+				if len(values) < min || isFailure(err) {
+					l.Restore(start)
+					return nil, err
+				}
+				break
+			}
+			values = append(values, value)
+			// A parser that matches without reading anything would loop forever
+			if l.Checkpoint() == before {
+				break
+			}
+		}
+		return values, nil
+	}
+}
+
+// RuleCombinator compiles a ParseRule into a Combinator.
+// Each step calls its ParseHandler with data exactly as Parse would.
+// On success the combinator returns the name of the rule.
+func (p *ParserObject) RuleCombinator(rule ParseRule, data interface{}) Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		start := l.Checkpoint()
+		result, err := p.parseMemoRule(l, &rule, &data)
+		if result != PARSE_RESULT_SUCCESS {
+			l.Restore(start)
+			if err == nil {
+				err = fmt.Errorf("rule %s did not match", rule.Name)
+			}
+			if result == PARSE_RESULT_FAILURE {
+				return nil, ruleFailure{err}
+			}
+			return nil, err
+		}
+		return rule.Name, nil
+	}
+}
+
+// isFailure reports whether an error comes from a rule that failed outright.
+func isFailure(err error) bool {
+	var failure ruleFailure
+	return errors.As(err, &failure)
+}
@@ -0,0 +1,152 @@
+package ParserCore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpect(t *testing.T) {
+	l := NewLexer(", :", nil)
+	value, err := Expect(COMMA)(l)
+	if err != nil || value != "," {
+		t.Errorf("Expect() failed, expected ',', got '%v' with error '%v'", value, err)
+	}
+	value, err = Expect(COMMA)(l)
+	if err == nil {
+		t.Errorf("Expect() failed, expected error for colon, got '%v'", value)
+	}
+	if tok := l.NextToken(); tok.Type != COLON {
+		t.Errorf("Expect() consumed a token on failure, next token is %s", TokenTypeNames[tok.Type])
+	}
+}
+
+func TestKeyword(t *testing.T) {
+	l := NewLexer("buy", nil)
+	value, err := Keyword("BUY", "SELL")(l)
+	if err != nil || value != "BUY" {
+		t.Errorf("Keyword() failed, expected 'BUY', got '%v' with error '%v'", value, err)
+	}
+}
+
+func TestSeq(t *testing.T) {
+	l := NewLexer("BUY 100 SHARES", nil)
+	p := ParserObject{}
+	value, err := Seq(Keyword("BUY"), p.StepCombinator(ParserRuleStep{ParserType: PARSE_ANY_INTEGER}), Keyword("SHARES"))(l)
+	expected := []interface{}{"BUY", 100, "SHARES"}
+	if err != nil || !reflect.DeepEqual(value, expected) {
+		t.Errorf("Seq() failed, expected %v, got '%v' with error '%v'", expected, value, err)
+	}
+
+	l = NewLexer("BUY SHARES", nil)
+	_, err = Seq(Keyword("BUY"), Expect(INTEGER))(l)
+	if err == nil {
+		t.Errorf("Seq() failed, expected error for missing integer")
+	}
+	if tok := l.NextToken(); tok.Value != "BUY" {
+		t.Errorf("Seq() did not backtrack on failure, next token is %s", tok.Value)
+	}
+}
+
+func TestAlt(t *testing.T) {
+	p := Alt(Seq(Keyword("DISPLAY"), Keyword("STOCK")), Seq(Keyword("DISPLAY"), Keyword("PORTFOLIO")))
+	l := NewLexer("DISPLAY PORTFOLIO", nil)
+	value, err := p(l)
+	expected := []interface{}{"DISPLAY", "PORTFOLIO"}
+	if err != nil || !reflect.DeepEqual(value, expected) {
+		t.Errorf("Alt() failed, expected %v, got '%v' with error '%v'", expected, value, err)
+	}
+
+	l = NewLexer("DISPLAY NOTHING", nil)
+	if value, err = p(l); err == nil {
+		t.Errorf("Alt() failed, expected error, got '%v'", value)
+	}
+}
+
+func TestOptional(t *testing.T) {
+	p := Seq(Keyword("BUY"), Optional(Expect(INTEGER), "1"), Expect(STRING))
+	l := NewLexer("BUY Futzco", nil)
+	value, err := p(l)
+	expected := []interface{}{"BUY", "1", "Futzco"}
+	if err != nil || !reflect.DeepEqual(value, expected) {
+		t.Errorf("Optional() failed, expected %v, got '%v' with error '%v'", expected, value, err)
+	}
+}
+
+func TestMany(t *testing.T) {
+	l := NewLexer("AAPL MSFT GOOG 12", nil)
+	value, err := Many(Expect(STRING))(l)
+	expected := []interface{}{"AAPL", "MSFT", "GOOG"}
+	if err != nil || !reflect.DeepEqual(value, expected) {
+		t.Errorf("Many() failed, expected %v, got '%v' with error '%v'", expected, value, err)
+	}
+
+	l = NewLexer("12", nil)
+	value, err = Many(Expect(STRING))(l)
+	if err != nil || len(value.([]interface{})) != 0 {
+		t.Errorf("Many() failed, expected empty list, got '%v' with error '%v'", value, err)
+	}
+}
+
+func TestMany1(t *testing.T) {
+	l := NewLexer("12", nil)
+	if value, err := Many1(Expect(STRING))(l); err == nil {
+		t.Errorf("Many1() failed, expected error, got '%v'", value)
+	}
+
+	l = NewLexer("AAPL", nil)
+	value, err := Many1(Expect(STRING))(l)
+	if err != nil || !reflect.DeepEqual(value, []interface{}{"AAPL"}) {
+		t.Errorf("Many1() failed, expected [AAPL], got '%v' with error '%v'", value, err)
+	}
+}
+
+func TestSepBy(t *testing.T) {
+	l := NewLexer("AAPL, MSFT, GOOG,", nil)
+	value, err := SepBy(Expect(STRING), Expect(COMMA))(l)
+	expected := []interface{}{"AAPL", "MSFT", "GOOG"}
+	if err != nil || !reflect.DeepEqual(value, expected) {
+		t.Errorf("SepBy() failed, expected %v, got '%v' with error '%v'", expected, value, err)
+	}
+	if tok := l.NextToken(); tok.Type != COMMA {
+		t.Errorf("SepBy() consumed the trailing separator, next token is %s", TokenTypeNames[tok.Type])
+	}
+
+	l = NewLexer("", nil)
+	value, err = SepBy(Expect(STRING), Expect(COMMA))(l)
+	if err != nil || len(value.([]interface{})) != 0 {
+		t.Errorf("SepBy() failed, expected empty list, got '%v' with error '%v'", value, err)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	l := NewLexer("< 100 >", nil)
+	value, err := Between(Expect(LESS_THAN), Expect(INTEGER), Expect(GREATER_THAN))(l)
+	if err != nil || value != "100" {
+		t.Errorf("Between() failed, expected '100', got '%v' with error '%v'", value, err)
+	}
+}
+
+func TestParserObject_RuleCombinator(t *testing.T) {
+	DO := DataObject{}
+	p := ParserObject{}
+	rule := ParseRule{
+		Name: "Quantity",
+		Steps: []ParserRuleStep{
+			{
+				Name:        "Number",
+				ParserType:  PARSE_ANY_INTEGER,
+				SkipOnError: PARSE_RESULT_SKIP_RULE,
+				ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+					do := (*data).(*DataObject)
+					do.TestInt = token.(int)
+					return PARSE_RESULT_SUCCESS, nil
+				},
+			},
+		},
+	}
+	l := NewLexer("BUY 100", nil)
+	value, err := Seq(Keyword("BUY"), p.RuleCombinator(rule, &DO))(l)
+	if err != nil || DO.TestInt != 100 {
+		t.Errorf("RuleCombinator() failed, expected 100, got '%v' with error '%v'", value, err)
+	}
+}
@@ -0,0 +1,388 @@
+package ParserCore
+
+// Dates, times and durations.
+// PARSE_DATE, PARSE_TIME and PARSE_DATETIME read a point in time as a time.Time, and PARSE_DURATION
+// reads a length of time as a time.Duration.  Each of them can read several tokens, since the lexer
+// splits "2026-11-01" into three integers and "14:30" into an integer, a colon and an integer.
+//
+// Absolute dates and times are matched against the layouts in DateLayouts, TimeLayouts and
+// DateTimeLayouts, using the layout syntax of the time package, and the longest match wins.
+// Relative forms, such as TOMORROW, FRIDAY, IN 3 DAYS or 2 HOURS AGO, are worked out from the
+// ParserObject's Clock, so tests can fix the time.  Dates and times are in the Clock's location.
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DateLayouts are the layouts PARSE_DATE and PARSE_DATETIME read dates with.
+// A date with no year is in the current year.
+var DateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"01/02/2006",
+	"January 2, 2006",
+	"January 2 2006",
+	"2 January 2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"2 Jan 2006",
+	"January 2",
+	"2 January",
+	"Jan 2",
+	"2 Jan",
+}
+
+// TimeLayouts are the layouts PARSE_TIME and PARSE_DATETIME read times of day with.
+var TimeLayouts = []string{
+	"15:04",
+	"15:04:05",
+	"3:04PM",
+	"3:04:05PM",
+	"3:04 PM",
+	"3PM",
+	"3 PM",
+}
+
+// DateTimeLayouts are the layouts PARSE_DATETIME reads a date and time written together with.
+// A date and a time written separately, as in "2026-11-01 AT 14:30", are read with the other layouts.
+var DateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// maxLayoutTokens is the most tokens a date or time layout is matched against
+const maxLayoutTokens = 12
+
+// timeUnit is a unit of time.  Days, weeks, months and years are counted on the calendar,
+// so that a day across a change to summer time is still a day.
+type timeUnit struct {
+	duration time.Duration
+	days     int
+	months   int
+}
+
+// timeUnits are the words for units of time
+var timeUnits = map[string]timeUnit{
+	"SECOND": {duration: time.Second}, "SECONDS": {duration: time.Second},
+	"SEC": {duration: time.Second}, "SECS": {duration: time.Second},
+	"MINUTE": {duration: time.Minute}, "MINUTES": {duration: time.Minute},
+	"MIN": {duration: time.Minute}, "MINS": {duration: time.Minute},
+	"HOUR": {duration: time.Hour}, "HOURS": {duration: time.Hour},
+	"HR": {duration: time.Hour}, "HRS": {duration: time.Hour},
+	"DAY": {days: 1}, "DAYS": {days: 1},
+	"WEEK": {days: 7}, "WEEKS": {days: 7},
+	"MONTH": {months: 1}, "MONTHS": {months: 1},
+	"YEAR": {months: 12}, "YEARS": {months: 12},
+}
+
+// span is a length of time made up of several units
+type span struct {
+	duration time.Duration
+	days     int
+	months   int
+}
+
+// from returns the time a span after, or before, a given time.
+func (s span) from(t time.Time, sign int) time.Time {
+	return t.AddDate(0, sign*s.months, sign*s.days).Add(time.Duration(sign) * s.duration)
+}
+
+// now returns the current time from the Clock, or time.Now if there is no Clock.
+func (p *ParserObject) now() time.Time {
+	if p.Clock != nil {
+		return p.Clock()
+	}
+	return time.Now()
+}
+
+// midnight returns the start of the day of a time.
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// atTime returns the given day at the time of day of another time.
+func atTime(day time.Time, clock time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), clock.Second(),
+		clock.Nanosecond(), day.Location())
+}
+
+// readWord reads a word if it is one of those given, returning it in upper case.
+func readWord(l *Lexer, words ...string) (string, bool) {
+	start := l.Checkpoint()
+	tok := l.NextToken()
+	if tok.Type == STRING {
+		word := strings.ToUpper(tok.Value)
+		for _, w := range words {
+			if word == w {
+				return word, true
+			}
+		}
+	}
+	l.Restore(start)
+	return "", false
+}
+
+// readLayout reads the longest run of tokens which matches one of the layouts.
+// Tokens written apart are matched with a single space between them.
+func readLayout(l *Lexer, layouts []string, now time.Time) (time.Time, bool) {
+	start := l.Checkpoint()
+	var texts []string
+	var ends []int
+	var b strings.Builder
+	var last Token
+	for len(texts) < maxLayoutTokens {
+		tok := l.NextToken()
+		if tok.Type == EOF || tok.Type == QUOTED_STRING {
+			break
+		}
+		if len(texts) > 0 && !adjacent(last, tok) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok.Raw)
+		texts = append(texts, strings.ToUpper(b.String()))
+		ends = append(ends, l.Checkpoint())
+		last = tok
+	}
+	for n := len(texts) - 1; n >= 0; n-- {
+		for _, layout := range layouts {
+			text := texts[n]
+			if !strings.Contains(layout, "06") {
+				// Parse a layout with no year in the current year, so that Feb 29 is only a date in leap years
+				text += " " + strconv.Itoa(now.Year())
+				layout += " 2006"
+			}
+			t, err := time.ParseInLocation(layout, text, now.Location())
+			if err != nil {
+				continue
+			}
+			l.Restore(ends[n])
+			return t, true
+		}
+	}
+	l.Restore(start)
+	return time.Time{}, false
+}
+
+// readCount reads how many of a unit there are, as digits, words or "a" or "an".
+func readCount(l *Lexer) (float64, bool) {
+	start := l.Checkpoint()
+	tok := l.NextToken()
+	if tok.Type == FLOAT {
+		if value, err := strconv.ParseFloat(tok.Value, 64); err == nil {
+			return value, true
+		}
+	}
+	if tok.Type == STRING && (strings.EqualFold(tok.Value, "A") || strings.EqualFold(tok.Value, "AN")) {
+		return 1, true
+	}
+	l.Restore(start)
+	if err, number := parseNumberWords(l, nil); err == nil && !number.Ordinal {
+		return float64(number.Value), true
+	}
+	l.Restore(start)
+	return 0, false
+}
+
+// readSpan reads one or more counts of units, such as "3 DAYS" or "1 HOUR AND 30 MINUTES".
+// Unless calendar is set, a day is 24 hours and months and years are not read.
+// Calendar days and longer must be whole numbers.
+func readSpan(l *Lexer, calendar bool) (span, bool) {
+	var s span
+	found := false
+	for {
+		start := l.Checkpoint()
+		if found {
+			// Parts can be joined by AND or a comma
+			if _, ok := readWord(l, "AND"); !ok {
+				parseComma(l, 0)
+			}
+		}
+		count, ok := readCount(l)
+		if !ok {
+			l.Restore(start)
+			return s, found
+		}
+		tok := l.NextToken()
+		unit, ok := timeUnits[strings.ToUpper(tok.Value)]
+		if !calendar && unit.days != 0 {
+			unit = timeUnit{duration: time.Duration(unit.days) * 24 * time.Hour}
+		}
+		if tok.Type != STRING || !ok || (!calendar && unit.months != 0) ||
+			(unit.duration == 0 && count != float64(int(count))) {
+			l.Restore(start)
+			return s, found
+		}
+		s.duration += time.Duration(count * float64(unit.duration))
+		s.days += int(count) * unit.days
+		s.months += int(count) * unit.months
+		found = true
+	}
+}
+
+// readRelative reads a time relative to now, such as "IN 3 DAYS" or "2 HOURS AGO".
+func readRelative(l *Lexer, now time.Time) (time.Time, bool) {
+	start := l.Checkpoint()
+	if _, ok := readWord(l, "IN"); ok {
+		if s, ok := readSpan(l, true); ok {
+			return s.from(now, 1), true
+		}
+		l.Restore(start)
+		return time.Time{}, false
+	}
+	if s, ok := readSpan(l, true); ok {
+		if _, ok := readWord(l, "AGO"); ok {
+			return s.from(now, -1), true
+		}
+	}
+	l.Restore(start)
+	return time.Time{}, false
+}
+
+// readDay reads a date as the midnight at its start.
+func readDay(l *Lexer, now time.Time) (time.Time, bool) {
+	start := l.Checkpoint()
+	if word, ok := readWord(l, "TODAY", "TOMORROW", "YESTERDAY"); ok {
+		return midnight(now).AddDate(0, 0, map[string]int{"TODAY": 0, "TOMORROW": 1, "YESTERDAY": -1}[word]), true
+	}
+	// A day of the week is the next one after today, whether or not it is preceded by NEXT
+	readWord(l, "NEXT")
+	tok := l.NextToken()
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if tok.Type == STRING && strings.EqualFold(tok.Value, day.String()) {
+			days := (int(day)-int(now.Weekday())+6)%7 + 1
+			return midnight(now).AddDate(0, 0, days), true
+		}
+	}
+	l.Restore(start)
+	if t, ok := readRelative(l, now); ok {
+		return midnight(t), true
+	}
+	if t, ok := readLayout(l, DateLayouts, now); ok {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// readClock reads a time of day, on today's date.
+func readClock(l *Lexer, now time.Time) (time.Time, bool) {
+	if word, ok := readWord(l, "NOON", "MIDNIGHT"); ok {
+		return midnight(now).Add(map[string]time.Duration{"NOON": 12 * time.Hour, "MIDNIGHT": 0}[word]), true
+	}
+	if t, ok := readLayout(l, TimeLayouts, now); ok {
+		return atTime(now, t), true
+	}
+	return time.Time{}, false
+}
+
+// dateError reports that the next token does not start a date, time or duration.
+func dateError(l *Lexer, expected string) error {
+	start := l.Checkpoint()
+	tok := l.NextToken()
+	l.Restore(start)
+	return newParseError(tok, expected)
+}
+
+// parseDate reads a date, such as 2026-11-01, Nov 1, TOMORROW, FRIDAY or IN 3 DAYS.
+// The value is the midnight at the start of the date.
+func parseDate(l *Lexer, now time.Time) (error, time.Time) {
+	if t, ok := readDay(l, now); ok {
+		return nil, t
+	}
+	return dateError(l, "date"), time.Time{}
+}
+
+// parseTime reads a time of day, such as 14:30, 2:30PM, NOON, NOW or IN 2 HOURS.
+// A time of day is on today's date.
+func parseTime(l *Lexer, now time.Time) (error, time.Time) {
+	if _, ok := readWord(l, "NOW"); ok {
+		return nil, now
+	}
+	if t, ok := readRelative(l, now); ok {
+		return nil, t
+	}
+	if t, ok := readClock(l, now); ok {
+		return nil, t
+	}
+	return dateError(l, "time"), time.Time{}
+}
+
+// parseDateTime reads a date and a time, such as 2026-11-01T14:30, TOMORROW AT 14:30, 14:30 ON FRIDAY,
+// NOW or IN 3 DAYS.  A date with no time is at midnight, and a time with no date is today.
+func parseDateTime(l *Lexer, now time.Time) (error, time.Time) {
+	if _, ok := readWord(l, "NOW"); ok {
+		return nil, now
+	}
+	if t, ok := readLayout(l, DateTimeLayouts, now); ok {
+		return nil, t
+	}
+	if t, ok := readRelative(l, now); ok {
+		return nil, t
+	}
+	if day, ok := readDay(l, now); ok {
+		start := l.Checkpoint()
+		readWord(l, "AT")
+		if clock, ok := readClock(l, now); ok {
+			return nil, atTime(day, clock)
+		}
+		l.Restore(start)
+		return nil, day
+	}
+	if clock, ok := readClock(l, now); ok {
+		start := l.Checkpoint()
+		readWord(l, "ON")
+		if day, ok := readDay(l, now); ok {
+			return nil, atTime(day, clock)
+		}
+		l.Restore(start)
+		return nil, clock
+	}
+	return dateError(l, "date and time"), time.Time{}
+}
+
+// durationText puts a duration in Go's syntax into lower case for time.ParseDuration, except for an M
+// on its own, since 1M is as likely to be a month or a million as a minute.  MS is still milliseconds.
+func durationText(text string) string {
+	runes := []rune(text)
+	for i, r := range runes {
+		if r == 'M' && (i+1 == len(runes) || unicode.ToUpper(runes[i+1]) != 'S') {
+			continue
+		}
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
+
+// parseDuration reads a length of time, such as 2 WEEKS, 1 HOUR AND 30 MINUTES or 1h30m.
+// Months and years vary in length, so they are not durations.
+func parseDuration(l *Lexer) (error, time.Duration) {
+	// Go's own syntax, as in 1h30m, must be written without spaces
+	start := l.Checkpoint()
+	first := l.NextToken()
+	text, last := first.Raw, first
+	var best time.Duration
+	end := -1
+	for {
+		tok := l.NextToken()
+		if !adjacent(last, tok) || tok.Type == EOF {
+			break
+		}
+		text, last = text+tok.Raw, tok
+		if d, err := time.ParseDuration(durationText(text)); err == nil {
+			best, end = d, l.Checkpoint()
+		}
+	}
+	if end >= 0 {
+		l.Restore(end)
+		return nil, best
+	}
+	l.Restore(start)
+
+	if s, ok := readSpan(l, false); ok {
+		return nil, s.duration
+	}
+	return dateError(l, "duration"), 0
+}
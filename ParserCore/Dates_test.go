@@ -0,0 +1,205 @@
+package ParserCore
+
+import (
+	"testing"
+	"time"
+)
+
+// testNow is the time the date tests are run at, a Wednesday afternoon
+var testNow = time.Date(2026, time.October, 14, 15, 4, 5, 0, time.UTC)
+
+func testDate(year int, month time.Month, day int, hour int, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func Test_parseDate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Time
+		next     string
+	}{
+		{"2026-11-01", testDate(2026, time.November, 1, 0, 0), ""},
+		{"2026/11/01 please", testDate(2026, time.November, 1, 0, 0), "please"},
+		{"11/01/2026", testDate(2026, time.November, 1, 0, 0), ""},
+		{"November 1, 2026", testDate(2026, time.November, 1, 0, 0), ""},
+		{"1 Nov 2026", testDate(2026, time.November, 1, 0, 0), ""},
+		{"nov 1 at noon", testDate(2026, time.November, 1, 0, 0), "at"},
+		{"today", testDate(2026, time.October, 14, 0, 0), ""},
+		{"TOMORROW", testDate(2026, time.October, 15, 0, 0), ""},
+		{"yesterday", testDate(2026, time.October, 13, 0, 0), ""},
+		{"Friday", testDate(2026, time.October, 16, 0, 0), ""},
+		{"next Wednesday", testDate(2026, time.October, 21, 0, 0), ""},
+		{"in 3 days", testDate(2026, time.October, 17, 0, 0), ""},
+		{"in two weeks", testDate(2026, time.October, 28, 0, 0), ""},
+		{"in a month", testDate(2026, time.November, 14, 0, 0), ""},
+		{"3 days ago", testDate(2026, time.October, 11, 0, 0), ""},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseDate(l, testNow)
+		if err != nil || !value.Equal(test.expected) {
+			t.Errorf("parseDate(%s) failed, expected %v, got %v with error '%v'", test.input, test.expected, value, err)
+			continue
+		}
+		if tok := l.NextToken(); tok.Raw != test.next {
+			t.Errorf("parseDate(%s) failed, expected '%s' next, got '%s'", test.input, test.next, tok.Raw)
+		}
+	}
+
+	// 2026 is not a leap year, and 2028 is
+	for _, now := range []time.Time{testNow, testNow.AddDate(2, 0, 0)} {
+		l := NewLexer("Feb 29", nil)
+		err, value := parseDate(l, now)
+		if now.Year() == 2028 && (err != nil || !value.Equal(testDate(2028, time.February, 29, 0, 0))) {
+			t.Errorf("parseDate(Feb 29) failed in 2028, expected Feb 29, got %v with error '%v'", value, err)
+		}
+		if now.Year() == 2026 && err == nil {
+			t.Errorf("parseDate(Feb 29) failed in 2026, expected an error, got %v", value)
+		}
+	}
+
+	for _, input := range []string{"2026-13-01", "soon", "next week", "in 3 apples", "3 days"} {
+		l := NewLexer(input, nil)
+		if err, _ := parseDate(l, testNow); err == nil {
+			t.Errorf("parseDate(%s) failed, expected an error", input)
+		}
+		if l.Checkpoint() != 0 {
+			t.Errorf("parseDate(%s) failed, expected no tokens read, got %d", input, l.Checkpoint())
+		}
+	}
+}
+
+func Test_parseTime(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Time
+	}{
+		{"14:30", testDate(2026, time.October, 14, 14, 30)},
+		{"9:05", testDate(2026, time.October, 14, 9, 5)},
+		{"2:30pm", testDate(2026, time.October, 14, 14, 30)},
+		{"2:30 PM", testDate(2026, time.October, 14, 14, 30)},
+		{"11am", testDate(2026, time.October, 14, 11, 0)},
+		{"noon", testDate(2026, time.October, 14, 12, 0)},
+		{"now", testNow},
+		{"in 2 hours", testNow.Add(2 * time.Hour)},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseTime(l, testNow)
+		if err != nil || !value.Equal(test.expected) {
+			t.Errorf("parseTime(%s) failed, expected %v, got %v with error '%v'", test.input, test.expected, value, err)
+		}
+	}
+	l := NewLexer("25:00", nil)
+	if err, _ := parseTime(l, testNow); err == nil {
+		t.Errorf("parseTime(25:00) failed, expected an error")
+	}
+}
+
+func Test_parseDateTime(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Time
+	}{
+		{"2026-11-01T14:30", testDate(2026, time.November, 1, 14, 30)},
+		{"2026-11-01T14:30:00Z", testDate(2026, time.November, 1, 14, 30)},
+		{"2026-11-01 14:30", testDate(2026, time.November, 1, 14, 30)},
+		{"tomorrow at 9:30am", testDate(2026, time.October, 15, 9, 30)},
+		{"14:30 on Friday", testDate(2026, time.October, 16, 14, 30)},
+		{"2026-11-01", testDate(2026, time.November, 1, 0, 0)},
+		{"14:30", testDate(2026, time.October, 14, 14, 30)},
+		{"in 3 days", testNow.AddDate(0, 0, 3)},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseDateTime(l, testNow)
+		if err != nil || !value.Equal(test.expected) {
+			t.Errorf("parseDateTime(%s) failed, expected %v, got %v with error '%v'", test.input, test.expected, value, err)
+		}
+		if tok := l.NextToken(); tok.Type != EOF {
+			t.Errorf("parseDateTime(%s) failed, expected all input read, got '%s' next", test.input, tok.Raw)
+		}
+	}
+
+	// AT with no time after it is left for the next step
+	l := NewLexer("tomorrow at market", nil)
+	err, value := parseDateTime(l, testNow)
+	if err != nil || !value.Equal(testDate(2026, time.October, 15, 0, 0)) || l.NextToken().Value != "at" {
+		t.Errorf("parseDateTime(tomorrow at market) failed, got %v with error '%v'", value, err)
+	}
+}
+
+func Test_parseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"2 weeks", 14 * 24 * time.Hour},
+		{"3 DAYS", 72 * time.Hour},
+		{"90 minutes", 90 * time.Minute},
+		{"an hour", time.Hour},
+		{"1.5 hours", 90 * time.Minute},
+		{"1 hour and 30 minutes", 90 * time.Minute},
+		{"1 hour, 30 minutes", 90 * time.Minute},
+		{"twenty seconds", 20 * time.Second},
+		{"1h30m", 90 * time.Minute},
+		{"250ms", 250 * time.Millisecond},
+		{"250MS", 250 * time.Millisecond},
+		{"2H", 2 * time.Hour},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseDuration(l)
+		if err != nil || value != test.expected {
+			t.Errorf("parseDuration(%s) failed, expected %v, got %v with error '%v'", test.input, test.expected, value, err)
+		}
+		if tok := l.NextToken(); tok.Type != EOF {
+			t.Errorf("parseDuration(%s) failed, expected all input read, got '%s' next", test.input, tok.Raw)
+		}
+	}
+
+	for _, input := range []string{"3 months", "2 years", "10", "soon", "1M", "1H30M"} {
+		l := NewLexer(input, nil)
+		if err, _ := parseDuration(l); err == nil {
+			t.Errorf("parseDuration(%s) failed, expected an error", input)
+		}
+		if l.Checkpoint() != 0 {
+			t.Errorf("parseDuration(%s) failed, expected no tokens read, got %d", input, l.Checkpoint())
+		}
+	}
+}
+
+func TestParserObject_Clock(t *testing.T) {
+	type order struct {
+		when time.Time
+		hold time.Duration
+	}
+	rules := []ParseRule{
+		{
+			Name: "Sell",
+			Steps: []ParserRuleStep{
+				{Name: "Sell", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"SELL", "AAPL"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "When", ParserType: PARSE_DATETIME, SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*order).when = token.(time.Time)
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+				{Name: "For", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"FOR"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_FAILURE},
+				{Name: "Hold", ParserType: PARSE_DURATION, SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*order).hold = token.(time.Duration)
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+			},
+		},
+	}
+	var o order
+	p := ParserObject{Input: "sell AAPL tomorrow at 14:30 for 2 weeks", Clock: func() time.Time { return testNow }}
+	parse, err := p.Parse(rules, &o)
+	if parse != PARSE_RESULT_SUCCESS || !o.when.Equal(testDate(2026, time.October, 15, 14, 30)) ||
+		o.hold != 14*24*time.Hour {
+		t.Errorf("Parse() failed, got %d %+v with error '%v'", parse, o, err)
+	}
+}
@@ -0,0 +1,230 @@
+package ParserCore
+
+// Expressions.
+// An ExpressionParser parses expressions such as "AAPL > 150 + 5%" by precedence climbing
+// (a Pratt parser), driven by a table of operators.  Each operator is a prefix, infix or postfix
+// operator with a precedence and, for infix operators, an associativity.  Operators are matched
+// against the text of a token, so they can be punctuation such as + and >, or words such as AND.
+//
+// Each operator can have an Apply callback to work out its value from the values of its operands.
+// Operators without one produce an *ExprNode, so with no callbacks at all the result is a tree.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The kinds of operator
+const (
+	OPERATOR_PREFIX = iota
+	OPERATOR_INFIX
+	OPERATOR_POSTFIX
+)
+
+// OperatorKindNames is a list of names for the kinds of operator.
+var OperatorKindNames = []string{
+	"OPERATOR_PREFIX",
+	"OPERATOR_INFIX",
+	"OPERATOR_POSTFIX",
+}
+
+// How an infix operator groups with others of the same precedence
+const (
+	ASSOC_LEFT  = iota // a - b - c is (a - b) - c
+	ASSOC_RIGHT        // a ^ b ^ c is a ^ (b ^ c)
+)
+
+// Operator describes one operator of an expression.
+// Symbol is the token text of the operator.  Words are matched in upper case, so AND matches and.
+// Operators with a higher Precedence bind more tightly.
+type Operator struct {
+	Symbol        string
+	Kind          int
+	Precedence    int
+	Associativity int
+	Apply         func(operands ...interface{}) (interface{}, error) // Works out the value, or nil for an ExprNode
+}
+
+// ExprNode is an operator applied to its operands.
+// The operands are the values of the operands: *ExprNode for operators with no Apply callback,
+// and the operand values otherwise.
+type ExprNode struct {
+	Op       string
+	Kind     int
+	Operands []interface{}
+	Token    Token // The operator token
+}
+
+// String writes the expression out in full, with every operator in brackets.
+func (n *ExprNode) String() string {
+	operands := make([]string, 0, len(n.Operands))
+	for _, operand := range n.Operands {
+		operands = append(operands, fmt.Sprint(operand))
+	}
+	switch n.Kind {
+	case OPERATOR_PREFIX:
+		return "(" + n.Op + " " + strings.Join(operands, " ") + ")"
+	case OPERATOR_POSTFIX:
+		return "(" + strings.Join(operands, " ") + " " + n.Op + ")"
+	}
+	return "(" + strings.Join(operands, " "+n.Op+" ") + ")"
+}
+
+// ExpressionParser parses expressions using a table of operators.
+// Operand reads a single operand.  If it is nil, an operand is any integer, float, word or quoted string
+// which is not itself an operator, and its value is the same value the matching PARSE_ANY_ step produces.
+type ExpressionParser struct {
+	Operators []Operator
+	Operand   Combinator
+}
+
+// operator returns the operator of the given kind matching a token, or nil if there is none.
+func (e *ExpressionParser) operator(tok Token, kind int) *Operator {
+	text := tok.Value
+	if tok.Type == STRING {
+		text = strings.ToUpper(text)
+	} else if tok.Type == QUOTED_STRING || tok.Type == EOF {
+		return nil
+	}
+	for i := range e.Operators {
+		op := &e.Operators[i]
+		if op.Kind == kind && strings.ToUpper(op.Symbol) == text {
+			return op
+		}
+	}
+	return nil
+}
+
+// isOperator reports whether a token is any kind of operator.
+func (e *ExpressionParser) isOperator(tok Token) bool {
+	for kind := range OperatorKindNames {
+		if e.operator(tok, kind) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Combinator returns a Combinator which parses an expression.
+func (e *ExpressionParser) Combinator() Combinator {
+	return func(l *Lexer) (interface{}, error) {
+		start := l.Checkpoint()
+		value, err := e.parse(l, 0)
+		if err != nil {
+			l.Restore(start)
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// parse reads an expression whose operators all have at least the given precedence.
+func (e *ExpressionParser) parse(l *Lexer, precedence int) (interface{}, error) {
+	var left interface{}
+	var err error
+	start := l.Checkpoint()
+	tok := l.NextToken()
+	if op := e.operator(tok, OPERATOR_PREFIX); op != nil {
+		operand, err := e.parse(l, op.Precedence)
+		if err != nil {
+			return nil, err
+		}
+		if left, err = e.apply(op, tok, operand); err != nil {
+			return nil, err
+		}
+	} else {
+		l.Restore(start)
+		if left, err = e.operand(l); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		start = l.Checkpoint()
+		tok = l.NextToken()
+		if op := e.operator(tok, OPERATOR_POSTFIX); op != nil && op.Precedence >= precedence {
+			if left, err = e.apply(op, tok, left); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		op := e.operator(tok, OPERATOR_INFIX)
+		if op == nil || op.Precedence < precedence {
+			l.Restore(start)
+			return left, nil
+		}
+		next := op.Precedence + 1
+		if op.Associativity == ASSOC_RIGHT {
+			next = op.Precedence
+		}
+		right, err := e.parse(l, next)
+		if err != nil {
+			return nil, err
+		}
+		if left, err = e.apply(op, tok, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// apply works out the value of an operator, either with its Apply callback or as an ExprNode.
+func (e *ExpressionParser) apply(op *Operator, tok Token, operands ...interface{}) (interface{}, error) {
+	if op.Apply == nil {
+		return &ExprNode{Op: op.Symbol, Kind: op.Kind, Operands: operands, Token: tok}, nil
+	}
+	value, err := op.Apply(operands...)
+	if err != nil {
+		perr := newParseError(tok)
+		perr.Message = "operator " + op.Symbol + " failed"
+		perr.Err = err
+		return nil, perr
+	}
+	return value, nil
+}
+
+// operand reads a single operand, using Operand if it is set.
+func (e *ExpressionParser) operand(l *Lexer) (interface{}, error) {
+	if e.Operand != nil {
+		return e.Operand(l)
+	}
+	start := l.Checkpoint()
+	tok := l.NextToken()
+	l.Restore(start)
+	var err error
+	var value interface{}
+	switch {
+	case tok.Type == INTEGER:
+		err, value = parseAnyInteger(l, 0)
+	case tok.Type == FLOAT:
+		err, value = parseAnyFloat(l, 0)
+	case tok.Type == QUOTED_STRING:
+		err, value = parseAnyQuotedString(l, 0)
+	case tok.Type == STRING && !e.isOperator(tok):
+		err, value = parseAnyString(l, 0)
+	default:
+		perr := newParseError(tok, "expression")
+		for _, op := range e.Operators {
+			if op.Kind == OPERATOR_PREFIX {
+				perr.Expected = mergeExpected(perr.Expected, []string{op.Symbol})
+			}
+		}
+		return nil, perr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// parseExpression reads the value of a PARSE_EXPRESSION step.
+func parseExpression(l *Lexer, e *ExpressionParser) (error, interface{}) {
+	if e == nil {
+		start := l.Checkpoint()
+		perr := newParseError(l.NextToken())
+		perr.Message = "expression step has no ExpressionParser"
+		l.Restore(start)
+		return perr, nil
+	}
+	value, err := e.Combinator()(l)
+	return err, value
+}
@@ -0,0 +1,109 @@
+package ParserCore
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var testOperators = []Operator{
+	{Symbol: "OR", Kind: OPERATOR_INFIX, Precedence: 1},
+	{Symbol: "AND", Kind: OPERATOR_INFIX, Precedence: 2},
+	{Symbol: "NOT", Kind: OPERATOR_PREFIX, Precedence: 3},
+	{Symbol: ">", Kind: OPERATOR_INFIX, Precedence: 4},
+	{Symbol: "=", Kind: OPERATOR_INFIX, Precedence: 4},
+	{Symbol: "+", Kind: OPERATOR_INFIX, Precedence: 5},
+	{Symbol: "-", Kind: OPERATOR_INFIX, Precedence: 5},
+	{Symbol: "^", Kind: OPERATOR_INFIX, Precedence: 6, Associativity: ASSOC_RIGHT},
+	{Symbol: "%", Kind: OPERATOR_POSTFIX, Precedence: 7},
+	{Symbol: "!", Kind: OPERATOR_POSTFIX, Precedence: 7},
+}
+
+func TestExpressionParser_Combinator(t *testing.T) {
+	e := &ExpressionParser{Operators: testOperators}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"AAPL", "AAPL"},
+		{"AAPL > 150 + 5%", "(AAPL > (150 + (5 %)))"},
+		{"1 + 2 + 3", "((1 + 2) + 3)"},
+		{"1+2", "(1 + 2)"},
+		{"AAPL>150+5%", "(AAPL > (150 + (5 %)))"},
+		{"AAPL>150-5", "(AAPL > (150 - 5))"},
+		{"2 ^ 3 ^ 2", "(2 ^ (3 ^ 2))"},
+		{"price > 100 and volume > 1 or sector = tech", "(((price > 100) AND (volume > 1)) OR (sector = tech))"},
+		{"not a = b", "(NOT (a = b))"},
+		{"5 ! %", "((5 !) %)"},
+		{"1.5 + 2", "(1.5 + 2)"},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		value, err := e.Combinator()(l)
+		if err != nil || fmt.Sprint(value) != test.expected {
+			t.Errorf("Combinator(%s) failed, expected %s, got %v with error '%v'", test.input, test.expected, value, err)
+		}
+		if tok := l.NextToken(); tok.Type != EOF {
+			t.Errorf("Combinator(%s) failed, left %s unread", test.input, tok.Value)
+		}
+	}
+
+	l := NewLexer("AAPL > AND", nil)
+	if value, err := e.Combinator()(l); err == nil {
+		t.Errorf("Combinator() failed, expected error for missing operand, got %v", value)
+	}
+	if tok := l.NextToken(); tok.Value != "AAPL" {
+		t.Errorf("Combinator() did not backtrack on failure, next token is %s", tok.Value)
+	}
+}
+
+func TestExpressionParser_Apply(t *testing.T) {
+	add := func(operands ...interface{}) (interface{}, error) {
+		return operands[0].(int) + operands[1].(int), nil
+	}
+	e := &ExpressionParser{
+		Operators: []Operator{
+			{Symbol: "+", Kind: OPERATOR_INFIX, Precedence: 1, Apply: add},
+			{Symbol: "=", Kind: OPERATOR_INFIX, Precedence: 0, Apply: func(operands ...interface{}) (interface{}, error) {
+				if operands[0] != operands[1] {
+					return nil, errors.New("not equal")
+				}
+				return true, nil
+			}},
+		},
+	}
+	l := NewLexer("1 + 2 + 3", nil)
+	if value, err := e.Combinator()(l); err != nil || value != 6 {
+		t.Errorf("Combinator() failed, expected 6, got %v with error '%v'", value, err)
+	}
+	l = NewLexer("1 + 2 = 4", nil)
+	value, err := e.Combinator()(l)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Err == nil || perr.Err.Error() != "not equal" || perr.Token.Value != "=" {
+		t.Errorf("Combinator() failed, expected error from Apply at =, got %v with error '%v'", value, err)
+	}
+}
+
+func TestParserObject_Expression(t *testing.T) {
+	DO := DataObject{}
+	rules := []ParseRule{
+		{
+			Name: "Alert",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"ALERT", "WHEN"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "Condition", ParserType: PARSE_EXPRESSION, Expression: &ExpressionParser{Operators: testOperators},
+					SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*DataObject).TestString = token.(*ExprNode).String()
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+			},
+		},
+	}
+	p := ParserObject{Input: "ALERT WHEN AAPL > 150 + 5%"}
+	parse, err := p.Parse(rules, &DO)
+	if parse != PARSE_RESULT_SUCCESS || DO.TestString != "(AAPL > (150 + (5 %)))" {
+		t.Errorf("Parse() failed, got %d %v with error '%v'", parse, DO, err)
+	}
+}
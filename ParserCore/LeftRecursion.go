@@ -0,0 +1,218 @@
+package ParserCore
+
+// Left recursion.
+// A rule is left-recursive if it can reach itself through PARSE_SUBRULE steps without reading
+// any input first, as in "a condition is a condition AND a comparison, or a comparison".
+// Parsed naively such a rule calls itself forever.  Instead, left-recursive rules are grown from
+// a seed, as described by Warth, Douglass and Millstein in "Packrat Parsers Can Support Left Recursion":
+// the rule's recursive use of itself first fails, so the rule matches through one of its other
+// alternatives.  That match is cached, and the rule is parsed again, with its recursive use now
+// returning the cached match.  This repeats for as long as each pass reads further than the last.
+//
+// Which rules are left-recursive is worked out from the SubRules and SubRuleSet of each step, before
+// any input is read.  While a rule grows, its ParseHandler calls are recorded rather than made, since
+// every pass but the last is thrown away.  Once the longest match has been found, the recorded calls
+// are made in order.  Their results can still reject the match, but they cannot change how it was parsed.
+// Mutually left-recursive rules are grown by whichever of them the parse reaches first.
+
+import (
+	"fmt"
+)
+
+// leftRules returns the rules a rule can start by running, before it reads any input.
+func leftRules(rule *ParseRule) []*ParseRule {
+	var rules []*ParseRule
+	for _, step := range rule.Steps {
+		if step.ParserType == PARSE_SUBRULE {
+			rules = append(rules, step.SubRules...)
+			if step.SubRuleSet != nil {
+				for i := range step.SubRuleSet.rules {
+					rules = append(rules, &step.SubRuleSet.rules[i])
+				}
+			}
+		}
+		if step.ParserType == PARSE_LIST && step.Element != nil {
+			// A list starts with its first item
+			rules = append(rules, leftRules(&ParseRule{Steps: []ParserRuleStep{*step.Element}})...)
+		}
+		if !canSkip(step) {
+			break
+		}
+	}
+	return rules
+}
+
+// canSkip reports whether a step can match without reading any input.
+func canSkip(step ParserRuleStep) bool {
+	return skippable(step, map[*ParseRule]bool{})
+}
+
+// skippable reports whether a step can match without reading any input, including through
+// a subrule which can.  The rules being checked are taken not to, so that loops of rules end.
+func skippable(step ParserRuleStep, checking map[*ParseRule]bool) bool {
+	if step.Options&PARSE_OPTION_OPTIONAL != 0 ||
+		(step.MaxCount != 0 && step.MinCount <= 0) ||
+		(step.ParserType == PARSE_STRING_CHOICE && step.Options&PARSE_OPTION_STRING_IS_OPTIONAL != 0) ||
+		step.SkipOnError == PARSE_RESULT_SKIP_STEP {
+		return true
+	}
+	switch step.ParserType {
+	case PARSE_SUBRULE:
+		for _, rule := range step.SubRules {
+			if matchesEmpty(rule, checking) {
+				return true
+			}
+		}
+		if step.SubRuleSet != nil {
+			for i := range step.SubRuleSet.rules {
+				if matchesEmpty(&step.SubRuleSet.rules[i], checking) {
+					return true
+				}
+			}
+		}
+	case PARSE_LIST:
+		return step.Element != nil && skippable(*step.Element, checking)
+	}
+	return false
+}
+
+// matchesEmpty reports whether every step of a rule can match without reading any input.
+func matchesEmpty(rule *ParseRule, checking map[*ParseRule]bool) bool {
+	if rule == nil || checking[rule] {
+		return false
+	}
+	checking[rule] = true
+	defer delete(checking, rule)
+	for _, step := range rule.Steps {
+		if !skippable(step, checking) {
+			return false
+		}
+	}
+	return true
+}
+
+// reachable returns every rule a rule can run, directly or through other rules, before it reads any input.
+// The result is cached on the lexer.
+func (l *Lexer) reachable(rule *ParseRule) map[*ParseRule]bool {
+	if reach, ok := l.leftReach[rule]; ok {
+		return reach
+	}
+	reach := map[*ParseRule]bool{}
+	pending := leftRules(rule)
+	for len(pending) > 0 {
+		next := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if next == nil || reach[next] {
+			continue
+		}
+		reach[next] = true
+		pending = append(pending, leftRules(next)...)
+	}
+	if l.leftReach == nil {
+		l.leftReach = map[*ParseRule]map[*ParseRule]bool{}
+	}
+	l.leftReach[rule] = reach
+	return reach
+}
+
+// isLeftRecursive reports whether a rule can run itself before reading any input.
+func (l *Lexer) isLeftRecursive(rule *ParseRule) bool {
+	return l.reachable(rule)[rule]
+}
+
+// parseLeftRecursive parses a left-recursive rule by growing it from a seed.
+func (p *ParserObject) parseLeftRecursive(l *Lexer, rule *ParseRule, data *interface{}) (int, error) {
+	start := l.Checkpoint()
+	if head := l.growing[start]; head != nil && head != rule && l.reachable(rule)[head] && l.reachable(head)[rule] {
+		// The rule is part of a larger left-recursive loop which another rule is growing here,
+		// so its result depends on how far that rule has grown and cannot be cached
+		return p.parseRule(l, *rule, data)
+	}
+	key := memoKey{rule, start}
+	if entry, ok := l.memo[key]; ok {
+		if p.Memoize {
+			l.memoStats.Hits++
+		}
+		l.replayCalls(entry.calls, data)
+		l.Restore(entry.end)
+		return entry.result, entry.err
+	}
+	if p.Memoize {
+		l.memoStats.Misses++
+	}
+
+	// The seed: when the rule uses itself here, it fails
+	seed := newParseError(l.NextToken())
+	seed.Rule = rule.Name
+	seed.Message = "left recursion in rule " + rule.Name
+	l.Restore(start)
+	entry := &memoEntry{result: PARSE_RESULT_SKIP_RULE, err: seed, end: start}
+	if l.memo == nil {
+		l.memo = map[memoKey]*memoEntry{}
+	}
+	if l.growing == nil {
+		l.growing = map[int]*ParseRule{}
+	}
+	l.memo[key] = entry
+	outerHead, outerCalls, deferring := l.growing[start], l.calls, l.deferCalls
+	l.growing[start] = rule
+	l.deferCalls = true
+	for pass := 1; ; pass++ {
+		l.Restore(start)
+		var calls []handlerCall
+		l.calls = &calls
+		result, err := p.parseRule(l, *rule, data)
+		IfDebug(p.Debug, fmt.Printf, "       Left-recursive rule %s, pass %d: %s at token %d\n",
+			rule.Name, pass, ResultNames[result], l.Checkpoint())
+		if result == PARSE_RESULT_SUCCESS && (entry.result != PARSE_RESULT_SUCCESS || l.Checkpoint() > entry.end) {
+			// It read further than last time, so try again
+			entry = &memoEntry{result: result, end: l.Checkpoint(), calls: calls}
+			l.memo[key] = entry
+			continue
+		}
+		if entry.result != PARSE_RESULT_SUCCESS {
+			entry = &memoEntry{result: result, err: err, end: start, calls: calls}
+			l.memo[key] = entry
+		}
+		break
+	}
+	l.calls, l.deferCalls = outerCalls, deferring
+	if outerHead != nil {
+		l.growing[start] = outerHead
+	} else {
+		delete(l.growing, start)
+	}
+
+	if entry.result == PARSE_RESULT_SUCCESS && !l.deferCalls {
+		// Now the rule has stopped growing, its handlers can be called
+		l.Restore(start)
+		if result, err := l.callDeferred(rule, entry.calls, data); result != PARSE_RESULT_SUCCESS {
+			entry = &memoEntry{result: result, err: err, end: start}
+			l.memo[key] = entry
+		}
+	}
+	for _, call := range entry.calls {
+		l.recordCall(call.step, call.value)
+	}
+	l.Restore(entry.end)
+	return entry.result, entry.err
+}
+
+// callDeferred makes the ParseHandler calls recorded while a left-recursive rule grew.
+// If a handler rejects its value, the rule fails or is skipped as the handler asks.
+func (l *Lexer) callDeferred(rule *ParseRule, calls []handlerCall, data *interface{}) (int, error) {
+	for _, call := range calls {
+		result, err := call.step.ParseHandler(nil, call.value, call.step.ParserType, data)
+		if result < 0 || result >= len(ResultNames) {
+			err = fmt.Errorf("ParseHandler returned unknown result %d", result)
+			result = PARSE_RESULT_FAILURE
+		}
+		switch result {
+		case PARSE_RESULT_SUCCESS, PARSE_RESULT_SKIP_STEP:
+			continue
+		default:
+			return result, handlerError(l, *rule, call.step, err)
+		}
+	}
+	return PARSE_RESULT_SUCCESS, nil
+}
@@ -0,0 +1,159 @@
+package ParserCore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParserObject_LeftRecursion(t *testing.T) {
+	record := func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+		terms := (*data).(*[]string)
+		*terms = append(*terms, token.(string))
+		return PARSE_RESULT_SUCCESS, nil
+	}
+	comparison := ParseRule{
+		Name: "Comparison",
+		Steps: []ParserRuleStep{
+			{Name: "Field", ParserType: PARSE_ANY_STRING, Options: PARSE_OPTION_CONVERT_TO_UPPERCASE,
+				SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: record},
+			{Name: "Op", ParserType: PARSE_GREATER_THAN, SkipOnError: PARSE_RESULT_SKIP_RULE},
+			{Name: "Value", ParserType: PARSE_ANY_INTEGER, SkipOnError: PARSE_RESULT_SKIP_RULE},
+		},
+	}
+	// A condition is a condition AND/OR a comparison, or just a comparison
+	condition := ParseRule{Name: "Condition"}
+	condition.Steps = []ParserRuleStep{
+		{Name: "Left", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&condition, &comparison},
+			SkipOnError: PARSE_RESULT_SKIP_RULE},
+		{Name: "Join", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"AND", "OR"},
+			Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: record},
+		{Name: "Right", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&comparison},
+			SkipOnError: PARSE_RESULT_SKIP_RULE},
+	}
+	rules := []ParseRule{
+		{
+			Name: "Screen",
+			Steps: []ParserRuleStep{
+				{Name: "Condition", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&condition, &comparison},
+					SkipOnError: PARSE_RESULT_SKIP_RULE},
+			},
+		},
+	}
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"price > 100", []string{"PRICE"}},
+		{"price > 100 AND volume > 1", []string{"PRICE", "AND", "VOLUME"}},
+		{"price > 100 and volume > 1 or cap > 5", []string{"PRICE", "AND", "VOLUME", "OR", "CAP"}},
+	}
+	for _, memoize := range []bool{false, true} {
+		for _, test := range tests {
+			var terms []string
+			p := ParserObject{Input: test.input, Memoize: memoize}
+			parse, err := p.Parse(rules, &terms)
+			if parse != PARSE_RESULT_SUCCESS || !reflect.DeepEqual(terms, test.expected) {
+				t.Errorf("Parse(%s) failed with Memoize %v, expected %v, got %d %v with error '%v'",
+					test.input, memoize, test.expected, parse, terms, err)
+			}
+		}
+	}
+}
+
+func TestParserObject_MutualLeftRecursion(t *testing.T) {
+	var a, b ParseRule
+	c := ParseRule{
+		Name: "C",
+		Steps: []ParserRuleStep{
+			{Name: "Y", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"Y"}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+		},
+	}
+	a = ParseRule{
+		Name: "A",
+		Steps: []ParserRuleStep{
+			{Name: "B", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&b}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+			{Name: "X", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"X"}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+		},
+	}
+	b = ParseRule{
+		Name: "B",
+		Steps: []ParserRuleStep{
+			{Name: "AorC", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&a, &c}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+		},
+	}
+	rules := []ParseRule{
+		{
+			Name: "Top",
+			Steps: []ParserRuleStep{
+				{Name: "A", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&a}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+			},
+		},
+	}
+	l := NewLexer("", nil)
+	if !l.isLeftRecursive(&a) || !l.isLeftRecursive(&b) || l.isLeftRecursive(&c) {
+		t.Errorf("isLeftRecursive() failed, expected A and B only")
+	}
+	var data interface{}
+	p := ParserObject{Input: "Y X X X"}
+	parse, err := p.Parse(rules, &data)
+	if parse != PARSE_RESULT_SUCCESS {
+		t.Errorf("Parse() failed, got %d with error '%v'", parse, err)
+	}
+}
+
+func TestParserObject_LeftRecursionThroughEmptyRule(t *testing.T) {
+	// A can run itself after E, which can match nothing
+	empty := ParseRule{
+		Name: "E",
+		Steps: []ParserRuleStep{
+			{Name: "Maybe", ParserType: PARSE_ANY_INTEGER, Options: PARSE_OPTION_OPTIONAL, SkipOnError: PARSE_RESULT_SKIP_RULE},
+		},
+	}
+	a := ParseRule{Name: "A"}
+	a.Steps = []ParserRuleStep{
+		{Name: "E", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&empty}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+		{Name: "A", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&a}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+		{Name: "Word", ParserType: PARSE_ANY_STRING, SkipOnError: PARSE_RESULT_SKIP_RULE},
+	}
+	l := NewLexer("", nil)
+	if !l.isLeftRecursive(&a) || l.isLeftRecursive(&empty) {
+		t.Errorf("isLeftRecursive() failed, expected A only")
+	}
+	rules := []ParseRule{
+		{
+			Name: "Top",
+			Steps: []ParserRuleStep{
+				{Name: "A", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&a}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+			},
+		},
+	}
+	var data interface{}
+	p := ParserObject{Input: "hello world"}
+	parse, err := p.Parse(rules, &data)
+	if parse != PARSE_RESULT_FAILURE || err == nil || !strings.Contains(err.Error(), "left recursion in rule A") {
+		t.Errorf("Parse() failed, expected left recursion error, got %d with error '%v'", parse, err)
+	}
+}
+
+func TestParserObject_LeftRecursionWithoutBase(t *testing.T) {
+	loop := ParseRule{Name: "Loop"}
+	loop.Steps = []ParserRuleStep{
+		{Name: "Loop", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&loop}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+		{Name: "Word", ParserType: PARSE_ANY_STRING, SkipOnError: PARSE_RESULT_SKIP_RULE},
+	}
+	rules := []ParseRule{
+		{
+			Name: "Top",
+			Steps: []ParserRuleStep{
+				{Name: "Loop", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&loop}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+			},
+		},
+	}
+	var data interface{}
+	p := ParserObject{Input: "hello world"}
+	parse, err := p.Parse(rules, &data)
+	if parse != PARSE_RESULT_FAILURE || err == nil || !strings.Contains(err.Error(), "left recursion in rule Loop") {
+		t.Errorf("Parse() failed, expected left recursion error, got %d with error '%v'", parse, err)
+	}
+}
@@ -8,12 +8,18 @@ package ParserCore
 // - FLOAT: Decimal numbers
 // - COMMA: Comma character
 // - COLON: Colon character
+// - Other punctuation, read from a table (see Punctuation)
 // - ERROR: Represents an error in tokenization
 // - EOF: End of file marker
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 var LexerVersion = "1.0.0"
@@ -37,6 +43,16 @@ const (
 	PLUS
 	PERCENT
 	EQUAL
+	MINUS
+	STAR
+	SLASH
+	LPAREN
+	RPAREN
+	SEMICOLON
+	PERIOD
+	AT
+	DOLLAR
+	HASH
 )
 
 // The names of the token types for easy reference.
@@ -56,6 +72,101 @@ var TokenTypeNames = []string{
 	"PLUS",
 	"PERCENT",
 	"EQUAL",
+	"MINUS",
+	"STAR",
+	"SLASH",
+	"LPAREN",
+	"RPAREN",
+	"SEMICOLON",
+	"PERIOD",
+	"AT",
+	"DOLLAR",
+	"HASH",
+}
+
+// RegisterTokenType adds a new token type with the given name, for use with punctuation
+// such as <= or ->.  Token types are global, so register them once, before any parsing starts.
+func RegisterTokenType(name string) TokenType {
+	TokenTypeNames = append(TokenTypeNames, name)
+	return TokenType(len(TokenTypeNames) - 1)
+}
+
+// tokenTypeName returns the name of a token type, or a placeholder for unknown types.
+func tokenTypeName(tokType TokenType) string {
+	if tokType < 0 || int(tokType) >= len(TokenTypeNames) {
+		return fmt.Sprintf("UNKNOWN(%d)", tokType)
+	}
+	return TokenTypeNames[tokType]
+}
+
+// Quote describes the delimiters of a quoted string.
+// Backslash escapes are decoded unless Raw is set, when the text is taken exactly as written.
+type Quote struct {
+	Open  rune
+	Close rune
+	Raw   bool
+}
+
+// DefaultQuotes are the quoted strings every lexer starts with: double and single quotes with
+// escapes, and backticks without.
+var DefaultQuotes = []Quote{
+	{'"', '"', false},
+	{'\'', '\'', false},
+	{'`', '`', true},
+}
+
+// NumberFormat controls how the lexer reads numbers.
+// Whatever the format, the Value of a number token is written the way strconv expects it,
+// with any grouping removed and a point for the decimal point.  Its Raw text is as written.
+type NumberFormat struct {
+	DecimalPoint rune // Separates the whole and fractional parts, '.' if not set
+	Thousands    rune // Groups thousands, as in 1,000,000, or 0 for no grouping
+	Underscores  bool // Allow underscores between digits, as in 1_000_000
+	Exponents    bool // Allow exponents, as in 1e6 and 2.5E-3
+	Prefixes     bool // Allow 0x, 0o and 0b prefixes for hex, octal and binary integers
+	LeadingDot   bool // Allow decimals with no whole part, as in .5
+	LeadingPlus  bool // Allow a leading +, as in +3.  Off by default, as 150+5 would be two numbers
+}
+
+// DefaultNumberFormat is the number format every lexer starts with.
+// Thousands are grouped with commas, so a list of numbers needs spaces after its commas.
+var DefaultNumberFormat = NumberFormat{
+	DecimalPoint: '.',
+	Thousands:    ',',
+	Underscores:  true,
+	Exponents:    true,
+	Prefixes:     true,
+	LeadingDot:   true,
+}
+
+// Punctuation maps a run of punctuation characters to the type of token it produces.
+type Punctuation struct {
+	Symbol string
+	Type   TokenType
+}
+
+// DefaultPunctuation is the punctuation every lexer starts with.
+// All of it is a single character, so it needs no sorting.
+var DefaultPunctuation = []Punctuation{
+	{",", COMMA},
+	{":", COLON},
+	{"?", QUESTION},
+	{"<", LESS_THAN},
+	{">", GREATER_THAN},
+	{"!", EXCLAMATION},
+	{"+", PLUS},
+	{"%", PERCENT},
+	{"=", EQUAL},
+	{"-", MINUS},
+	{"*", STAR},
+	{"/", SLASH},
+	{"(", LPAREN},
+	{")", RPAREN},
+	{";", SEMICOLON},
+	{".", PERIOD},
+	{"@", AT},
+	{"$", DOLLAR},
+	{"#", HASH},
 }
 
 // Token represents a single token in the input string.
@@ -63,17 +174,32 @@ type Token struct {
 	Type   TokenType
 	Value  string
 	Line   int
-	Column int
+	Column int    // Counted in characters (runes), from 1
+	Offset int    // Byte offset of the start of the token in the input
+	Raw    string // The token exactly as it appears in the input, such as a quoted string with its quotes
 }
 
 // The core lexer object iself
+// Tokens are buffered as they are read, so the lexer can be rewound to any earlier
+// token with Checkpoint and Restore without lexing the input a second time.
 type Lexer struct {
 	input          string
 	pos            int
 	line           int
 	column         int
 	ignoredStrings []string
-	lastToken      *Token // Added field to store last token
+	tokens         []Token       // Every token read so far
+	index          int           // Position in tokens of the next token to return
+	punctuation    []Punctuation // Longest symbols first, or nil for DefaultPunctuation
+	quotes         []Quote       // Quoted string delimiters, or nil for DefaultQuotes
+	numbers        *NumberFormat // How numbers are read, or nil for DefaultNumberFormat
+	memo           map[memoKey]*memoEntry
+	memoStats      MemoStats
+	calls          *[]handlerCall // ParseHandler calls made by the cached rule being parsed
+	deferCalls     bool           // Set while a left-recursive rule grows, so handlers are recorded but not called
+	growing        map[int]*ParseRule
+	leftReach      map[*ParseRule]map[*ParseRule]bool
+	anchors        map[*regexp.Regexp]*regexp.Regexp // Regular expressions of PARSE_REGEX steps, anchored to match whole texts
 }
 
 // NewLexer creates a new Lexer instance with the provided input string.
@@ -84,151 +210,376 @@ func NewLexer(input string, exclude []string) *Lexer {
 		line:           1,
 		column:         1,
 		ignoredStrings: exclude,
-		lastToken:      nil,
 	}
 }
 
+// AddPunctuation adds punctuation to the lexer's table, replacing any entry with the same symbol.
+// Symbols are matched longest first, so adding <= still leaves < on its own as LESS_THAN.
+func (l *Lexer) AddPunctuation(entries ...Punctuation) {
+	if l.punctuation == nil {
+		l.punctuation = append([]Punctuation{}, DefaultPunctuation...)
+	}
+	for _, entry := range entries {
+		found := false
+		for i := range l.punctuation {
+			if l.punctuation[i].Symbol == entry.Symbol {
+				l.punctuation[i].Type = entry.Type
+				found = true
+			}
+		}
+		if !found {
+			l.punctuation = append(l.punctuation, entry)
+		}
+	}
+	sort.SliceStable(l.punctuation, func(i, j int) bool {
+		return len(l.punctuation[i].Symbol) > len(l.punctuation[j].Symbol)
+	})
+}
+
+// SetNumberFormat changes how the lexer reads numbers.
+func (l *Lexer) SetNumberFormat(format NumberFormat) {
+	l.numbers = &format
+}
+
+// numberFormat returns the lexer's number format, with the decimal point filled in.
+func (l *Lexer) numberFormat() NumberFormat {
+	format := DefaultNumberFormat
+	if l.numbers != nil {
+		format = *l.numbers
+	}
+	if format.DecimalPoint == 0 {
+		format.DecimalPoint = '.'
+	}
+	return format
+}
+
+// SetQuotes replaces the delimiters the lexer recognises as quoted strings.
+func (l *Lexer) SetQuotes(quotes ...Quote) {
+	l.quotes = append([]Quote{}, quotes...)
+}
+
 // SetIgnoredStrings sets the list of strings to be ignored during tokenization
 func (l *Lexer) SetIgnoredStrings(ignored []string) {
 	l.ignoredStrings = ignored
 }
 
 // PushBack pushes back the last read token so it can be read again
+// To go back further than one token, use Checkpoint and Restore.
 func (l *Lexer) PushBack(token Token) {
-	l.lastToken = &token
+	if l.index > 0 && l.tokens[l.index-1] == token {
+		l.index--
+		return
+	}
+	// Anything else is inserted into the stream ahead of the next token
+	l.tokens = append(l.tokens[:l.index], append([]Token{token}, l.tokens[l.index:]...)...)
+}
+
+// Checkpoint returns the current position in the token stream
+func (l *Lexer) Checkpoint() int {
+	return l.index
 }
 
-// The workhorse of the system -- NextToken reads the next token from the input string.
+// Restore rewinds the token stream to a position returned by Checkpoint
+// Any number of tokens can be rewound, so a parse can backtrack to any earlier point.
+func (l *Lexer) Restore(checkpoint int) {
+	l.index = checkpoint
+}
+
+// Tokenize reads the whole input into the token buffer and returns the tokens, ending with EOF.
+// The position of the stream is left unchanged.
+func (l *Lexer) Tokenize() []Token {
+	checkpoint := l.Checkpoint()
+	l.index = len(l.tokens)
+	for len(l.tokens) == 0 || l.tokens[len(l.tokens)-1].Type != EOF {
+		l.NextToken()
+	}
+	l.Restore(checkpoint)
+	return l.tokens
+}
+
+// NextToken returns the next token in the stream, reading more of the input if required.
+// Once the end of the input is reached, NextToken keeps returning the same EOF token.
 func (l *Lexer) NextToken() Token {
-	if l.lastToken != nil {
-		token := *l.lastToken
-		l.lastToken = nil
+	if l.index < len(l.tokens) {
+		token := l.tokens[l.index]
+		l.index++
 		return token
 	}
+	if len(l.tokens) > 0 && l.tokens[len(l.tokens)-1].Type == EOF {
+		return l.tokens[len(l.tokens)-1]
+	}
+	token := l.scanToken()
+	l.tokens = append(l.tokens, token)
+	l.index++
+	return token
+}
 
-	l.skipWhitespace()
+// scanToken reads the next token from the input string, skipping any ignored strings.
+func (l *Lexer) scanToken() Token {
+	for {
+		l.skipWhitespace()
+		offset := l.pos
+		token := l.readToken()
+		token.Offset = offset
+		token.Raw = l.input[offset:l.pos]
+		if token.Type != STRING || !l.shouldIgnore(token.Value) {
+			return token
+		}
+	}
+}
 
+// The workhorse of the system -- readToken reads the token starting at the current position.
+func (l *Lexer) readToken() Token {
 	if l.pos >= len(l.input) {
 		return Token{Type: EOF, Line: l.line, Column: l.column}
 	}
 
+	r, _ := l.peek(0)
+	// An apostrophe straight after a word, as in "the stocks' price", does not open a quoted string
+	if quote, ok := l.quote(r); ok && !(r == '\'' && isWordRune(l.previous())) {
+		return l.readQuotedString(quote)
+	}
 	switch {
-	case l.input[l.pos] == '"':
-		return l.readQuotedString()
-	case l.input[l.pos] == ',':
-		token := Token{Type: COMMA, Value: string(l.input[l.pos]), Line: l.line, Column: l.column}
-		l.pos++
-		l.column++
-		return token
-	case l.input[l.pos] == ':':
-		token := Token{Type: COLON, Value: string(l.input[l.pos]), Line: l.line, Column: l.column}
-		l.pos++
-		l.column++
-		return token
-	case l.input[l.pos] == '?':
-		token := Token{Type: QUESTION, Value: string(l.input[l.pos]), Line: l.line, Column: l.column}
-		l.pos++
-		l.column++
-		return token
-	case l.input[l.pos] == '<':
-		token := Token{Type: LESS_THAN, Value: string(l.input[l.pos]), Line: l.line, Column: l.column}
-		l.pos++
-		l.column++
-		return token
-	case l.input[l.pos] == '>':
-		token := Token{Type: GREATER_THAN, Value: string(l.input[l.pos]), Line: l.line, Column: l.column}
-		l.pos++
-		l.column++
-		return token
-	case l.input[l.pos] == '!':
-		token := Token{Type: EXCLAMATION, Value: string(l.input[l.pos]), Line: l.line, Column: l.column}
-		l.pos++
-		l.column++
-		return token
-	case l.input[l.pos] == '+':
-		token := Token{Type: PLUS, Value: string(l.input[l.pos]), Line: l.line, Column: l.column}
-		l.pos++
-		l.column++
-		return token
-	case l.input[l.pos] == '%':
-		token := Token{Type: PERCENT, Value: string(l.input[l.pos]), Line: l.line, Column: l.column}
-		l.pos++
-		l.column++
+	case l.startsNumber():
+		return l.readNumber()
+	case unicode.IsLetter(r) || unicode.IsDigit(r):
+		return l.readString()
+	}
+	if token, ok := l.readPunctuation(); ok {
 		return token
-	case l.input[l.pos] == '=':
-		token := Token{Type: EQUAL, Value: string(l.input[l.pos]), Line: l.line, Column: l.column}
-		l.pos++
+	}
+	token := Token{Type: ERROR, Value: string(r), Line: l.line, Column: l.column}
+	l.advance()
+	return token
+}
+
+// previous returns the rune before the current position, or 0 at the start of the input.
+func (l *Lexer) previous() rune {
+	if l.pos == 0 {
+		return 0
+	}
+	r, _ := utf8.DecodeLastRuneInString(l.input[:l.pos])
+	return r
+}
+
+// peek decodes the rune at the given byte offset from the current position, returning it and its size.
+// At the end of the input it returns 0.
+func (l *Lexer) peek(offset int) (rune, int) {
+	if l.pos+offset >= len(l.input) {
+		return 0, 0
+	}
+	return utf8.DecodeRuneInString(l.input[l.pos+offset:])
+}
+
+// peekAfter returns the rune after the one at the current position
+func (l *Lexer) peekAfter() rune {
+	return l.lookahead(1)
+}
+
+// lookahead returns the rune n runes after the current position, or 0 past the end of the input.
+func (l *Lexer) lookahead(n int) rune {
+	offset := 0
+	for ; n > 0; n-- {
+		_, size := l.peek(offset)
+		if size == 0 {
+			return 0
+		}
+		offset += size
+	}
+	r, _ := l.peek(offset)
+	return r
+}
+
+// advance moves past the rune at the current position, keeping the line and column up to date.
+// Columns count runes, not bytes.
+func (l *Lexer) advance() rune {
+	r, size := l.peek(0)
+	l.pos += size
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
 		l.column++
-		return token
-	case unicode.IsDigit(rune(l.input[l.pos])) || l.input[l.pos] == '-':
-		return l.readNumber()
-	case unicode.IsLetter(rune(l.input[l.pos])):
-		if token := l.readString(); !l.shouldIgnore(token.Value) {
-			return token
+	}
+	return r
+}
+
+// isDigit reports whether a rune is an ASCII digit.  Other digits are read as part of words.
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// isWordRune reports whether a rune can be part of a word.
+// Marks are included so that letters written with combining accents stay in one word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// readPunctuation reads the longest punctuation symbol in the table starting at the current position.
+func (l *Lexer) readPunctuation() (Token, bool) {
+	table := l.punctuation
+	if table == nil {
+		table = DefaultPunctuation
+	}
+	for _, entry := range table {
+		if entry.Symbol != "" && strings.HasPrefix(l.input[l.pos:], entry.Symbol) {
+			token := Token{Type: entry.Type, Value: entry.Symbol, Line: l.line, Column: l.column}
+			l.pos += len(entry.Symbol)
+			l.column += utf8.RuneCountInString(entry.Symbol)
+			return token, true
 		}
-		return l.NextToken()
-	default:
-		return Token{Type: ERROR, Value: string(l.input[l.pos]), Line: l.line, Column: l.column}
 	}
+	return Token{}, false
 }
 
-func (l *Lexer) readQuotedString() Token {
-	startPos := l.pos
+// quote returns the quote which opens with the given rune, if there is one.
+func (l *Lexer) quote(r rune) (Quote, bool) {
+	quotes := l.quotes
+	if quotes == nil {
+		quotes = DefaultQuotes
+	}
+	for _, quote := range quotes {
+		if quote.Open == r {
+			return quote, true
+		}
+	}
+	return Quote{}, false
+}
+
+// readQuotedString reads a quoted string.  The token's Value is the text between the quotes
+// with any escapes decoded, and its Raw text is the string as written, quotes and all.
+// A string with an invalid escape is read up to its closing quote and returned as an ERROR.
+func (l *Lexer) readQuotedString(quote Quote) Token {
+	startLine := l.line
 	startColumn := l.column
-	l.pos++ // Skip opening quote
-	l.column++
-
-	for l.pos < len(l.input) && l.input[l.pos] != '"' {
-		if l.input[l.pos] == '\n' {
-			l.line++
-			l.column = 1
-		} else {
-			l.column++
+	l.advance() // Skip opening quote
+
+	var value strings.Builder
+	invalid := false
+	for {
+		if l.pos >= len(l.input) {
+			return Token{Type: ERROR, Value: "Unterminated string", Line: startLine, Column: startColumn}
+		}
+		r := l.advance()
+		switch {
+		case r == quote.Close && invalid:
+			return Token{Type: ERROR, Value: "Invalid escape sequence", Line: startLine, Column: startColumn}
+		case r == quote.Close:
+			return Token{
+				Type:   QUOTED_STRING,
+				Value:  value.String(),
+				Line:   startLine,
+				Column: startColumn,
+			}
+		case r == '\\' && !quote.Raw:
+			escaped, ok := l.readEscape(quote)
+			invalid = invalid || !ok
+			value.WriteRune(escaped)
+		default:
+			value.WriteRune(r)
 		}
-		l.pos++
 	}
+}
 
+// readEscape decodes the escape sequence following a backslash.
+// Any of the default quotes can be escaped, as well as the string's own delimiters.
+func (l *Lexer) readEscape(quote Quote) (rune, bool) {
 	if l.pos >= len(l.input) {
-		return Token{Type: ERROR, Value: "Unterminated string", Line: l.line, Column: startColumn}
+		return 0, false
 	}
+	r := l.advance()
+	switch r {
+	case 'n':
+		return '\n', true
+	case 't':
+		return '\t', true
+	case 'r':
+		return '\r', true
+	case '\\', '"', '\'', '`', quote.Open, quote.Close:
+		return r, true
+	case 'u':
+		if l.pos+4 > len(l.input) {
+			return 0, false
+		}
+		code, err := strconv.ParseUint(l.input[l.pos:l.pos+4], 16, 32)
+		if err != nil {
+			return 0, false
+		}
+		for i := 0; i < 4; i++ {
+			l.advance()
+		}
+		return rune(code), true
+	}
+	return 0, false
+}
 
-	l.pos++ // Skip closing quote
-	l.column++
-
-	return Token{
-		Type:   QUOTED_STRING,
-		Value:  l.input[startPos:l.pos],
-		Line:   l.line,
-		Column: startColumn,
+// startsNumber reports whether a number starts at the current position.
+func (l *Lexer) startsNumber() bool {
+	format := l.numberFormat()
+	n := 0
+	if r := l.lookahead(0); r == '-' || (r == '+' && format.LeadingPlus) {
+		// A sign straight after a word, a number or a closing bracket is an operator, so 150-5 is a subtraction
+		if before := l.previous(); isWordRune(before) || before == ')' || before == ']' || before == '}' {
+			return false
+		}
+		n++
+	}
+	if isDigit(l.lookahead(n)) {
+		return true
+	}
+	// A leading point must not follow a word or another point, so that 1..5 and e.5 stay as they are
+	if !format.LeadingDot || l.lookahead(n) != format.DecimalPoint || !isDigit(l.lookahead(n+1)) {
+		return false
 	}
+	if n == 0 && l.pos > 0 {
+		before := l.previous()
+		return !isWordRune(before) && before != format.DecimalPoint
+	}
+	return true
 }
 
+// readNumber reads an integer or float.  The token's Value is the number ready for strconv.
 func (l *Lexer) readNumber() Token {
-	startPos := l.pos
+	format := l.numberFormat()
 	startColumn := l.column
+	var value strings.Builder
 	isFloat := false
 
-	// Handle negative sign
-	if l.input[l.pos] == '-' {
-		l.pos++
-		l.column++
-		if l.pos >= len(l.input) || !unicode.IsDigit(rune(l.input[l.pos])) {
-			return Token{Type: ERROR, Value: "Invalid number", Line: l.line, Column: startColumn}
+	// Handle the sign
+	if r := l.lookahead(0); r == '-' || r == '+' {
+		value.WriteRune(l.advance())
+	}
+
+	// Hex, octal and binary integers
+	if format.Prefixes && l.lookahead(0) == '0' {
+		if base := prefixBase(l.lookahead(1)); base != 0 && isBaseDigit(l.lookahead(2), base) {
+			value.WriteRune(l.advance())
+			value.WriteRune(l.advance())
+			l.readDigits(&value, format, base, false)
+			return Token{Type: INTEGER, Value: value.String(), Line: l.line, Column: startColumn}
 		}
 	}
 
-	for l.pos < len(l.input) {
-		if l.input[l.pos] == '.' && !isFloat {
-			isFloat = true
-			l.pos++
-			l.column++
-			continue
+	l.readDigits(&value, format, 10, true)
+	// A point only belongs to the number if a digit follows it, so 1..5 and "100." end at the point
+	if l.lookahead(0) == format.DecimalPoint && isDigit(l.lookahead(1)) {
+		isFloat = true
+		l.advance()
+		value.WriteRune('.')
+		l.readDigits(&value, format, 10, false)
+	}
+	if format.Exponents && (l.lookahead(0) == 'e' || l.lookahead(0) == 'E') {
+		n := 1
+		if sign := l.lookahead(1); sign == '-' || sign == '+' {
+			n++
 		}
-		if !unicode.IsDigit(rune(l.input[l.pos])) {
-			break
+		if isDigit(l.lookahead(n)) {
+			isFloat = true
+			for ; n > 0; n-- {
+				value.WriteRune(l.advance())
+			}
+			l.readDigits(&value, format, 10, false)
 		}
-		l.pos++
-		l.column++
 	}
 
 	tokenType := INTEGER
@@ -238,19 +589,75 @@ func (l *Lexer) readNumber() Token {
 
 	return Token{
 		Type:   tokenType,
-		Value:  l.input[startPos:l.pos],
+		Value:  value.String(),
 		Line:   l.line,
 		Column: startColumn,
 	}
 }
 
+// readDigits reads a run of digits in the given base, skipping underscores and, if grouped is set,
+// thousands separators.  A separator only counts if exactly three digits follow it.
+func (l *Lexer) readDigits(value *strings.Builder, format NumberFormat, base int, grouped bool) {
+	count := 0  // Digits read since the start or the last separator
+	groups := 0 // Thousands separators read
+	for {
+		r := l.lookahead(0)
+		switch {
+		case isBaseDigit(r, base):
+			value.WriteRune(l.advance())
+			count++
+		case r == '_' && format.Underscores && count > 0 && isBaseDigit(l.lookahead(1), base):
+			l.advance()
+		case grouped && r != 0 && r == format.Thousands && isThousandsGroup(l) &&
+			((groups == 0 && count > 0 && count <= 3) || count == 3):
+			l.advance()
+			groups++
+			count = 0
+		default:
+			return
+		}
+	}
+}
+
+// isThousandsGroup reports whether the separator at the current position is followed by exactly three digits.
+func isThousandsGroup(l *Lexer) bool {
+	return isDigit(l.lookahead(1)) && isDigit(l.lookahead(2)) && isDigit(l.lookahead(3)) && !isDigit(l.lookahead(4))
+}
+
+// prefixBase returns the base given by the letter after a leading 0, or 0 if it is not a prefix.
+func prefixBase(r rune) int {
+	switch r {
+	case 'x', 'X':
+		return 16
+	case 'o', 'O':
+		return 8
+	case 'b', 'B':
+		return 2
+	}
+	return 0
+}
+
+// isBaseDigit reports whether a rune is a digit in the given base.
+func isBaseDigit(r rune, base int) bool {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r-'0') < base
+	case r >= 'a' && r <= 'f', r >= 'A' && r <= 'F':
+		return base == 16
+	}
+	return false
+}
+
 func (l *Lexer) readString() Token {
 	startPos := l.pos
 	startColumn := l.column
 
-	for l.pos < len(l.input) && (unicode.IsLetter(rune(l.input[l.pos])) || unicode.IsDigit(rune(l.input[l.pos]))) {
-		l.pos++
-		l.column++
+	for r, _ := l.peek(0); l.pos < len(l.input); r, _ = l.peek(0) {
+		// An apostrophe between letters, as in McDonald's, is part of the word rather than a quote
+		if !isWordRune(r) && !(r == '\'' && l.pos > startPos && isWordRune(l.peekAfter())) {
+			break
+		}
+		l.advance()
 	}
 
 	return Token{
@@ -262,17 +669,8 @@ func (l *Lexer) readString() Token {
 }
 
 func (l *Lexer) skipWhitespace() {
-	for l.pos < len(l.input) {
-		if l.input[l.pos] == '\n' {
-			l.line++
-			l.column = 1
-			l.pos++
-		} else if unicode.IsSpace(rune(l.input[l.pos])) {
-			l.column++
-			l.pos++
-		} else {
-			break
-		}
+	for r, _ := l.peek(0); l.pos < len(l.input) && unicode.IsSpace(r); r, _ = l.peek(0) {
+		l.advance()
 	}
 }
 
@@ -22,3 +22,238 @@ func TestLexer_readString(t *testing.T) {
 		fmt.Printf("%s %s\n", TokenTypeNames[tok.Type], tok.Value)
 	}
 }
+
+func TestLexer_Checkpoint(t *testing.T) {
+	l := NewLexer("DISPLAY STOCK AAPL", nil)
+	start := l.Checkpoint()
+	l.NextToken()
+	l.NextToken()
+	l.Restore(start)
+	if tok := l.NextToken(); tok.Value != "DISPLAY" {
+		t.Errorf("Restore() failed, expected 'DISPLAY', got '%s'", tok.Value)
+	}
+	tokens := l.Tokenize()
+	if len(tokens) != 4 || tokens[3].Type != EOF {
+		t.Errorf("Tokenize() failed, expected 3 tokens and EOF, got %v", tokens)
+	}
+	if tok := l.NextToken(); tok.Value != "STOCK" {
+		t.Errorf("Tokenize() moved the stream, expected 'STOCK', got '%s'", tok.Value)
+	}
+	l.NextToken()
+	if tok := l.NextToken(); tok.Type != EOF {
+		t.Errorf("NextToken() failed, expected EOF, got '%s'", tok.Value)
+	}
+	if tok := l.NextToken(); tok.Type != EOF {
+		t.Errorf("NextToken() failed, expected EOF to repeat, got '%s'", tok.Value)
+	}
+}
+
+func TestLexer_PushBack(t *testing.T) {
+	l := NewLexer("BUY SELL", nil)
+	tok := l.NextToken()
+	l.PushBack(tok)
+	if again := l.NextToken(); again != tok {
+		t.Errorf("PushBack() failed, expected '%s', got '%s'", tok.Value, again.Value)
+	}
+	l.PushBack(Token{Type: STRING, Value: "NOW"})
+	if again := l.NextToken(); again.Value != "NOW" {
+		t.Errorf("PushBack() failed, expected 'NOW', got '%s'", again.Value)
+	}
+	if next := l.NextToken(); next.Value != "SELL" {
+		t.Errorf("PushBack() failed, expected 'SELL', got '%s'", next.Value)
+	}
+}
+
+func TestLexer_Punctuation(t *testing.T) {
+	l := NewLexer("a-b * (c / d); e. @f $5 #1 -2 1..5", nil)
+	expected := []TokenType{STRING, MINUS, STRING, STAR, LPAREN, STRING, SLASH, STRING, RPAREN, SEMICOLON,
+		STRING, PERIOD, AT, STRING, DOLLAR, INTEGER, HASH, INTEGER, INTEGER, INTEGER, PERIOD, PERIOD, INTEGER, EOF}
+	for i, tokType := range expected {
+		if tok := l.NextToken(); tok.Type != tokType {
+			t.Errorf("NextToken() failed at token %d, expected %s, got %s '%s'",
+				i, TokenTypeNames[tokType], TokenTypeNames[tok.Type], tok.Value)
+			return
+		}
+	}
+}
+
+func TestLexer_AddPunctuation(t *testing.T) {
+	lessEqual := RegisterTokenType("LESS_EQUAL")
+	notEqual := RegisterTokenType("NOT_EQUAL")
+	arrow := RegisterTokenType("ARROW")
+	rangeTo := RegisterTokenType("RANGE")
+	l := NewLexer("a <= b < c != d ! -> 1..5", nil)
+	l.AddPunctuation(Punctuation{"<=", lessEqual}, Punctuation{"!=", notEqual},
+		Punctuation{"->", arrow}, Punctuation{"..", rangeTo})
+	expected := []Token{
+		{Type: STRING, Value: "a"}, {Type: lessEqual, Value: "<="}, {Type: STRING, Value: "b"},
+		{Type: LESS_THAN, Value: "<"}, {Type: STRING, Value: "c"}, {Type: notEqual, Value: "!="},
+		{Type: STRING, Value: "d"}, {Type: EXCLAMATION, Value: "!"}, {Type: arrow, Value: "->"},
+		{Type: INTEGER, Value: "1"}, {Type: rangeTo, Value: ".."}, {Type: INTEGER, Value: "5"},
+	}
+	for _, exp := range expected {
+		if tok := l.NextToken(); tok.Type != exp.Type || tok.Value != exp.Value {
+			t.Errorf("NextToken() failed, expected %s '%s', got %s '%s'",
+				TokenTypeNames[exp.Type], exp.Value, TokenTypeNames[tok.Type], tok.Value)
+		}
+	}
+}
+
+func TestLexer_Unicode(t *testing.T) {
+	// Café is written with a combining accent, and \xff is not valid UTF-8
+	l := NewLexer("Société Générale\nNestlé 100 トヨタ 7203 \"Müller AG\" x Cafe\u0301 \xff", nil)
+	expected := []Token{
+		{Type: STRING, Value: "Société", Line: 1, Column: 1, Offset: 0, Raw: "Société"},
+		{Type: STRING, Value: "Générale", Line: 1, Column: 9, Offset: 10, Raw: "Générale"},
+		{Type: STRING, Value: "Nestlé", Line: 2, Column: 1, Offset: 21, Raw: "Nestlé"},
+		{Type: INTEGER, Value: "100", Line: 2, Column: 8, Offset: 29, Raw: "100"},
+		{Type: STRING, Value: "トヨタ", Line: 2, Column: 12, Offset: 33, Raw: "トヨタ"},
+		{Type: INTEGER, Value: "7203", Line: 2, Column: 16, Offset: 43, Raw: "7203"},
+		{Type: QUOTED_STRING, Value: "Müller AG", Line: 2, Column: 21, Offset: 48, Raw: "\"Müller AG\""},
+		{Type: STRING, Value: "x", Line: 2, Column: 33, Offset: 61, Raw: "x"},
+		{Type: STRING, Value: "Cafe\u0301", Line: 2, Column: 35, Offset: 63, Raw: "Cafe\u0301"},
+		{Type: ERROR, Value: "�", Line: 2, Column: 41, Offset: 70, Raw: "\xff"},
+		{Type: EOF, Line: 2, Column: 42, Offset: 71},
+	}
+	for _, exp := range expected {
+		if tok := l.NextToken(); tok != exp {
+			t.Errorf("NextToken() failed, expected %+v, got %+v", exp, tok)
+		}
+	}
+}
+
+func TestLexer_readQuotedString(t *testing.T) {
+	tests := []struct {
+		input string
+		value string
+	}{
+		{`"Say \"hi\""`, `Say "hi"`},
+		{`"back\\slash\ttab\nline"`, "back\\slash\ttab\nline"},
+		{`"café"`, "café"},
+		{`'single "quoted"'`, `single "quoted"`},
+		{`'it\'s'`, "it's"},
+		{`"don\'t"`, "don't"},
+		{`'say \"hi\"'`, `say "hi"`},
+		{"\"a \\` tick\"", "a ` tick"},
+		{"`raw \\n string`", `raw \n string`},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		if tok := l.NextToken(); tok.Type != QUOTED_STRING || tok.Value != test.value || tok.Raw != test.input {
+			t.Errorf("readQuotedString(%s) failed, expected '%s', got %s '%s' raw '%s'",
+				test.input, test.value, TokenTypeNames[tok.Type], tok.Value, tok.Raw)
+		}
+	}
+	for _, input := range []string{`"unterminated`, `"bad \q escape"`, `"bad \u12"`} {
+		l := NewLexer(input, nil)
+		if tok := l.NextToken(); tok.Type != ERROR {
+			t.Errorf("readQuotedString(%s) failed, expected ERROR, got %s '%s'", input, TokenTypeNames[tok.Type], tok.Value)
+		}
+	}
+
+	// A string with an invalid escape is one ERROR token, and lexing carries on after it
+	l := NewLexer(`"a\qb" SHARES OF X`, nil)
+	for _, expected := range []TokenType{ERROR, STRING, STRING, STRING, EOF} {
+		if tok := l.NextToken(); tok.Type != expected {
+			t.Errorf("readQuotedString() failed, expected %s, got %s '%s'", TokenTypeNames[expected], TokenTypeNames[tok.Type], tok.Raw)
+		}
+	}
+
+	// An apostrophe at the end of a word does not start a quoted string either
+	l = NewLexer("the stocks' price", nil)
+	for _, expected := range []string{"the", "stocks", "'", "price"} {
+		if tok := l.NextToken(); tok.Raw != expected || tok.Type == QUOTED_STRING {
+			t.Errorf("readQuotedString() failed, expected '%s', got %s '%s'", expected, TokenTypeNames[tok.Type], tok.Raw)
+		}
+	}
+
+	// An apostrophe inside a word does not start a quoted string
+	l = NewLexer("McDonald's 'Big Mac'", nil)
+	if tok := l.NextToken(); tok.Type != STRING || tok.Value != "McDonald's" {
+		t.Errorf("readString() failed, expected McDonald's, got %s '%s'", TokenTypeNames[tok.Type], tok.Value)
+	}
+	if tok := l.NextToken(); tok.Type != QUOTED_STRING || tok.Value != "Big Mac" {
+		t.Errorf("readQuotedString() failed, expected 'Big Mac', got %s '%s'", TokenTypeNames[tok.Type], tok.Value)
+	}
+
+	l = NewLexer("«Futzco» \"Inc\"", nil)
+	l.SetQuotes(Quote{'«', '»', false})
+	if tok := l.NextToken(); tok.Type != QUOTED_STRING || tok.Value != "Futzco" {
+		t.Errorf("SetQuotes() failed, expected Futzco, got %s '%s'", TokenTypeNames[tok.Type], tok.Value)
+	}
+	if tok := l.NextToken(); tok.Type == QUOTED_STRING {
+		t.Errorf("SetQuotes() failed, expected double quotes to no longer be a quoted string")
+	}
+}
+
+func TestLexer_readNumber(t *testing.T) {
+	tests := []struct {
+		input   string
+		tokType TokenType
+		value   string
+	}{
+		{"123", INTEGER, "123"},
+		{"-123.45", FLOAT, "-123.45"},
+		{"1e6", FLOAT, "1e6"},
+		{"2.5E-3", FLOAT, "2.5E-3"},
+		{".5", FLOAT, ".5"},
+		{"-.5", FLOAT, "-.5"},
+		{"0x1F", INTEGER, "0x1F"},
+		{"0o17", INTEGER, "0o17"},
+		{"0b101", INTEGER, "0b101"},
+		{"1_000_000", INTEGER, "1000000"},
+		{"1,000", INTEGER, "1000"},
+		{"12,345,678.5", FLOAT, "12345678.5"},
+		{"007", INTEGER, "007"},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		tok := l.NextToken()
+		if tok.Type != test.tokType || tok.Value != test.value || tok.Raw != test.input {
+			t.Errorf("readNumber(%s) failed, expected %s '%s', got %s '%s'",
+				test.input, TokenTypeNames[test.tokType], test.value, TokenTypeNames[tok.Type], tok.Value)
+		}
+		if next := l.NextToken(); next.Type != EOF {
+			t.Errorf("readNumber(%s) failed, left '%s' unread", test.input, next.Raw)
+		}
+	}
+
+	// Separators only group thousands if three digits follow
+	l := NewLexer("1,2, 1,0000 3e 0x", nil)
+	expected := []string{"1", ",", "2", ",", "1", ",", "0000", "3", "e", "0", "x"}
+	for _, value := range expected {
+		if tok := l.NextToken(); tok.Value != value {
+			t.Errorf("readNumber() failed, expected '%s', got '%s'", value, tok.Value)
+		}
+	}
+
+	// A leading plus has to be turned on, otherwise 150+5 would be two numbers
+	l = NewLexer("+3 150+5", nil)
+	for _, value := range []string{"+", "3", "150", "+", "5"} {
+		if tok := l.NextToken(); tok.Raw != value {
+			t.Errorf("readNumber() failed, expected '%s', got '%s'", value, tok.Raw)
+		}
+	}
+	// A minus straight after a word, a number or a closing bracket is an operator
+	l = NewLexer("150-5 x-1 (2)-3 5 -3 -4", nil)
+	for _, value := range []string{"150", "-", "5", "x", "-", "1", "(", "2", ")", "-", "3", "5", "-3", "-4"} {
+		if tok := l.NextToken(); tok.Raw != value {
+			t.Errorf("readNumber() failed, expected '%s', got '%s'", value, tok.Raw)
+		}
+	}
+	l = NewLexer("+3", nil)
+	format := DefaultNumberFormat
+	format.LeadingPlus = true
+	l.SetNumberFormat(format)
+	if tok := l.NextToken(); tok.Type != INTEGER || tok.Value != "+3" {
+		t.Errorf("SetNumberFormat() failed, expected INTEGER '+3', got %s '%s'", TokenTypeNames[tok.Type], tok.Value)
+	}
+
+	l = NewLexer("1.234.567,89 1,5", nil)
+	l.SetNumberFormat(NumberFormat{DecimalPoint: ',', Thousands: '.'})
+	for _, value := range []string{"1234567.89", "1.5"} {
+		if tok := l.NextToken(); tok.Type != FLOAT || tok.Value != value {
+			t.Errorf("SetNumberFormat() failed, expected FLOAT '%s', got %s '%s'", value, TokenTypeNames[tok.Type], tok.Value)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package ParserCore
+
+// Lists.
+// A PARSE_LIST step reads a list written the way people write lists, such as "AAPL, MSFT and GOOG",
+// "AAPL, MSFT, or TSLA" or "AAPL and MSFT".  Each item is read by the step's Element step, and the
+// items are separated by commas, by a conjunction such as AND or OR, or by a comma and a conjunction.
+// The handler is passed a List with the value of each item and the conjunction that was used.
+//
+// A list only uses one conjunction, so in "AAPL and MSFT or TSLA" the list is "AAPL and MSFT"
+// and "or TSLA" is left for the next step.  So is a separator with no item after it.
+// The Element step's own ParseHandler is not called.
+
+import (
+	"strings"
+)
+
+// DefaultConjunctions are the words which join the items of a list if the step does not give any.
+var DefaultConjunctions = []string{"AND", "OR"}
+
+// List is the value of a PARSE_LIST step.
+type List struct {
+	Items       []interface{} // The value of each item, as the Element step reads it
+	Conjunction string        // The conjunction used, in upper case, or "" if there was none
+}
+
+// parseList reads a list of items separated by commas and conjunctions.
+func (p *ParserObject) parseList(l *Lexer, step ParserRuleStep, data *interface{}) (error, List) {
+	start := l.Checkpoint()
+	if step.Element == nil {
+		perr := newParseError(l.NextToken())
+		perr.Message = "list step has no Element"
+		l.Restore(start)
+		return perr, List{}
+	}
+	conjunctions := step.Conjunctions
+	if conjunctions == nil {
+		conjunctions = DefaultConjunctions
+	}
+	words := make([]string, 0, len(conjunctions))
+	for _, word := range conjunctions {
+		words = append(words, strings.ToUpper(word))
+	}
+
+	element := p.stepCombinator(*step.Element, data)
+	value, err := element(l)
+	if err != nil {
+		l.Restore(start)
+		return err, List{}
+	}
+	list := List{Items: []interface{}{value}}
+	for {
+		mark := l.Checkpoint()
+		commaErr, _ := parseComma(l, 0)
+		word, joined := readWord(l, words...)
+		if (commaErr != nil && !joined) || (joined && list.Conjunction != "" && word != list.Conjunction) {
+			l.Restore(mark)
+			break
+		}
+		value, err := element(l)
+		if err != nil {
+			if isFailure(err) {
+				l.Restore(start)
+				return err, List{}
+			}
+			l.Restore(mark)
+			break
+		}
+		list.Items = append(list.Items, value)
+		if joined {
+			list.Conjunction = word
+		}
+	}
+	return nil, list
+}
@@ -0,0 +1,84 @@
+package ParserCore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParserObject_parseList(t *testing.T) {
+	ticker := &ParserRuleStep{Name: "Ticker", ParserType: PARSE_ANY_STRING, Options: PARSE_OPTION_CONVERT_TO_UPPERCASE}
+	tests := []struct {
+		input    string
+		expected List
+		next     string
+	}{
+		{"AAPL", List{Items: []interface{}{"AAPL"}}, ""},
+		{"AAPL, MSFT and GOOG", List{Items: []interface{}{"AAPL", "MSFT", "GOOG"}, Conjunction: "AND"}, ""},
+		{"AAPL, MSFT, or TSLA", List{Items: []interface{}{"AAPL", "MSFT", "TSLA"}, Conjunction: "OR"}, ""},
+		{"aapl and msft", List{Items: []interface{}{"AAPL", "MSFT"}, Conjunction: "AND"}, ""},
+		{"AAPL, MSFT, GOOG", List{Items: []interface{}{"AAPL", "MSFT", "GOOG"}}, ""},
+		{"AAPL and MSFT or TSLA", List{Items: []interface{}{"AAPL", "MSFT"}, Conjunction: "AND"}, "or"},
+		{"AAPL, MSFT, 10", List{Items: []interface{}{"AAPL", "MSFT"}}, ","},
+		{"AAPL and", List{Items: []interface{}{"AAPL"}}, "and"},
+	}
+	p := ParserObject{}
+	var data interface{}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := p.parseList(l, ParserRuleStep{ParserType: PARSE_LIST, Element: ticker}, &data)
+		if err != nil || !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("parseList(%s) failed, expected %+v, got %+v with error '%v'", test.input, test.expected, value, err)
+			continue
+		}
+		if tok := l.NextToken(); tok.Raw != test.next {
+			t.Errorf("parseList(%s) failed, expected '%s' next, got '%s'", test.input, test.next, tok.Raw)
+		}
+	}
+
+	// Other conjunctions
+	l := NewLexer("10 to 20", nil)
+	step := ParserRuleStep{ParserType: PARSE_LIST, Conjunctions: []string{"to"},
+		Element: &ParserRuleStep{ParserType: PARSE_ANY_INTEGER}}
+	err, value := p.parseList(l, step, &data)
+	expected := List{Items: []interface{}{10, 20}, Conjunction: "TO"}
+	if err != nil || !reflect.DeepEqual(value, expected) {
+		t.Errorf("parseList(10 to 20) failed, expected %+v, got %+v with error '%v'", expected, value, err)
+	}
+
+	for _, input := range []string{"10, 20", ", AAPL"} {
+		l := NewLexer(input, nil)
+		err, _ := p.parseList(l, ParserRuleStep{ParserType: PARSE_LIST, Element: ticker}, &data)
+		if err == nil || l.Checkpoint() != 0 {
+			t.Errorf("parseList(%s) failed, expected an error with no tokens read, got '%v'", input, err)
+		}
+	}
+}
+
+func TestParserObject_List(t *testing.T) {
+	rules := []ParseRule{
+		{
+			Name: "Watch",
+			Steps: []ParserRuleStep{
+				{Name: "Watch", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"WATCH"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "Stocks", ParserType: PARSE_LIST, SkipOnError: PARSE_RESULT_FAILURE,
+					Element: &ParserRuleStep{Name: "Stock", ParserType: PARSE_WORDS_UNTIL,
+						StopWords: []string{"AND", "OR"}, StopTypes: []TokenType{COMMA}},
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						stocks := (*data).(*[]string)
+						for _, item := range token.(List).Items {
+							*stocks = append(*stocks, item.(Phrase).Text)
+						}
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+			},
+		},
+	}
+	var stocks []string
+	p := ParserObject{Input: "WATCH AAPL, Bank of America, and General Motors"}
+	parse, err := p.Parse(rules, &stocks)
+	expected := []string{"AAPL", "Bank of America", "General Motors"}
+	if parse != PARSE_RESULT_SUCCESS || !reflect.DeepEqual(stocks, expected) {
+		t.Errorf("Parse() failed, expected %v, got %d %v with error '%v'", expected, parse, stocks, err)
+	}
+}
@@ -0,0 +1,123 @@
+package ParserCore
+
+// Regex and custom steps.
+// Not every kind of token deserves its own step type.  A PARSE_REGEX step matches the text of the
+// input against a regular expression, so ISINs, CUSIPs and order IDs can be recognised without
+// changing the library.  A PARSE_CUSTOM step runs any Combinator, for anything a regular
+// expression cannot describe.
+//
+// The lexer splits text such as "ORD-12345" into several tokens, so a PARSE_REGEX step matches
+// the longest run of tokens written without spaces between them which the expression matches in full.
+// A quoted string is matched on its own, without its quotes.
+
+import (
+	"errors"
+	"regexp"
+)
+
+// RegexMatch is the value of a PARSE_REGEX step.
+type RegexMatch struct {
+	Text   string            // The text which matched
+	Groups []string          // The capture groups, with the whole match first, as from FindStringSubmatch
+	Named  map[string]string // The named capture groups
+}
+
+// anchored returns a regular expression which only matches the whole of its input.
+// Anchored expressions are cached on the lexer.
+func (l *Lexer) anchored(re *regexp.Regexp) *regexp.Regexp {
+	if a, ok := l.anchors[re]; ok {
+		return a
+	}
+	if l.anchors == nil {
+		l.anchors = map[*regexp.Regexp]*regexp.Regexp{}
+	}
+	a := regexp.MustCompile(`^(?:` + re.String() + `)$`)
+	l.anchors[re] = a
+	return a
+}
+
+// regexMatch matches a whole text, returning its capture groups.
+func regexMatch(re *regexp.Regexp, text string) (RegexMatch, bool) {
+	groups := re.FindStringSubmatch(text)
+	if groups == nil {
+		return RegexMatch{}, false
+	}
+	match := RegexMatch{Text: text, Groups: groups}
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			if match.Named == nil {
+				match.Named = map[string]string{}
+			}
+			match.Named[name] = groups[i]
+		}
+	}
+	return match, true
+}
+
+// parseRegex reads the longest run of adjacent tokens which the expression matches in full.
+func parseRegex(l *Lexer, re *regexp.Regexp) (error, RegexMatch) {
+	start := l.Checkpoint()
+	first := l.NextToken()
+	if re == nil {
+		perr := newParseError(first)
+		perr.Message = "regex step has no Regex"
+		l.Restore(start)
+		return perr, RegexMatch{}
+	}
+	anchored := l.anchored(re)
+	if first.Type == QUOTED_STRING {
+		if match, ok := regexMatch(anchored, first.Value); ok {
+			return nil, match
+		}
+		l.Restore(start)
+		return newParseError(first, re.String()), RegexMatch{}
+	}
+
+	var best RegexMatch
+	end := -1
+	text := ""
+	last := first
+	for tok := first; tok.Type != EOF && tok.Type != QUOTED_STRING; tok = l.NextToken() {
+		if tok != first && !adjacent(last, tok) {
+			break
+		}
+		text += tok.Raw
+		last = tok
+		if match, ok := regexMatch(anchored, text); ok {
+			best, end = match, l.Checkpoint()
+		}
+	}
+	if end < 0 {
+		l.Restore(start)
+		return newParseError(first, re.String()), RegexMatch{}
+	}
+	l.Restore(end)
+	return nil, best
+}
+
+// parseCustom runs the Matcher of a PARSE_CUSTOM step.
+// If it fails, whatever it read is put back, and an error which is not already a ParseError
+// is given the position of the token it failed at.
+func parseCustom(l *Lexer, matcher Combinator) (error, interface{}) {
+	start := l.Checkpoint()
+	if matcher == nil {
+		perr := newParseError(l.NextToken())
+		perr.Message = "custom step has no Matcher"
+		l.Restore(start)
+		return perr, nil
+	}
+	value, err := matcher(l)
+	if err == nil {
+		return nil, value
+	}
+	l.Restore(start)
+	var perr *ParseError
+	var failure ruleFailure
+	if errors.As(err, &perr) || errors.As(err, &failure) {
+		return err, nil
+	}
+	perr = newParseError(l.NextToken())
+	perr.Err = err
+	l.Restore(start)
+	return perr, nil
+}
@@ -0,0 +1,104 @@
+package ParserCore
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_parseRegex(t *testing.T) {
+	isin := regexp.MustCompile(`(?P<country>[A-Z]{2})([A-Z0-9]{9})(\d)`)
+	order := regexp.MustCompile(`ORD-(\d+)`)
+	tests := []struct {
+		re       *regexp.Regexp
+		input    string
+		expected RegexMatch
+		next     string
+	}{
+		{isin, "US0378331005 now", RegexMatch{Text: "US0378331005",
+			Groups: []string{"US0378331005", "US", "037833100", "5"}, Named: map[string]string{"country": "US"}}, "now"},
+		{order, "ORD-12345 please", RegexMatch{Text: "ORD-12345", Groups: []string{"ORD-12345", "12345"}}, "please"},
+		{order, "ORD-12345-9", RegexMatch{Text: "ORD-12345", Groups: []string{"ORD-12345", "12345"}}, "-"},
+		{order, "\"ORD-7\"", RegexMatch{Text: "ORD-7", Groups: []string{"ORD-7", "7"}}, ""},
+		{regexp.MustCompile(`a|ab`), "ab", RegexMatch{Text: "ab", Groups: []string{"ab"}}, ""},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseRegex(l, test.re)
+		if err != nil || !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("parseRegex(%s) failed, expected %+v, got %+v with error '%v'", test.input, test.expected, value, err)
+			continue
+		}
+		if tok := l.NextToken(); tok.Raw != test.next {
+			t.Errorf("parseRegex(%s) failed, expected '%s' next, got '%s'", test.input, test.next, tok.Raw)
+		}
+	}
+
+	for _, input := range []string{"ORD 12345", "US037833100", "ORDER-1"} {
+		l := NewLexer(input, nil)
+		err, _ := parseRegex(l, order)
+		if err == nil || l.Checkpoint() != 0 {
+			t.Errorf("parseRegex(%s) failed, expected an error with no tokens read, got '%v'", input, err)
+		}
+	}
+}
+
+func Test_parseCustom(t *testing.T) {
+	// A CUSIP is nine characters whose last is a check digit
+	cusip := func(l *Lexer) (interface{}, error) {
+		tok := l.NextToken()
+		if len(tok.Raw) != 9 {
+			return nil, errors.New("a CUSIP has nine characters")
+		}
+		return strings.ToUpper(tok.Raw), nil
+	}
+	l := NewLexer("037833100 shares", nil)
+	err, value := parseCustom(l, cusip)
+	if err != nil || value != "037833100" {
+		t.Errorf("parseCustom() failed, expected 037833100, got %v with error '%v'", value, err)
+	}
+
+	l = NewLexer("0378 shares", nil)
+	err, _ = parseCustom(l, cusip)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Column != 1 || !strings.Contains(err.Error(), "nine characters") {
+		t.Errorf("parseCustom() failed, expected a ParseError at column 1, got '%v'", err)
+	}
+	if l.Checkpoint() != 0 {
+		t.Errorf("parseCustom() failed, expected no tokens read, got %d", l.Checkpoint())
+	}
+}
+
+func TestParserObject_RegexAndCustom(t *testing.T) {
+	type cancel struct {
+		order string
+		why   interface{}
+	}
+	rules := []ParseRule{
+		{
+			Name: "Cancel",
+			Steps: []ParserRuleStep{
+				{Name: "Cancel", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"CANCEL"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "Order", ParserType: PARSE_REGEX, Regex: regexp.MustCompile(`ORD-(\d+)`), SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*cancel).order = token.(RegexMatch).Groups[1]
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+				{Name: "Reason", ParserType: PARSE_CUSTOM, Matcher: Keyword("NOW", "LATER"), SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*cancel).why = token
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+			},
+		},
+	}
+	var c cancel
+	p := ParserObject{Input: "cancel ORD-42 now"}
+	parse, err := p.Parse(rules, &c)
+	if parse != PARSE_RESULT_SUCCESS || c.order != "42" || c.why != "NOW" {
+		t.Errorf("Parse() failed, expected order 42 NOW, got %d %+v with error '%v'", parse, c, err)
+	}
+}
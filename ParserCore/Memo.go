@@ -0,0 +1,148 @@
+package ParserCore
+
+// Packrat memoization.
+// Once rules nest and backtrack, the same sub-rule can be parsed again and again at the same
+// place in the input, for example when several rules start with the same sub-rule.
+// With ParserObject.Memoize set, the result of each sub-rule at each token position is kept
+// in a table on the lexer, so a sub-rule is only ever parsed once at any position and the time
+// a parse takes grows linearly with the input.
+//
+// A cached rule still has to fill in the data object, so the ParseHandler calls it made are
+// recorded with the result and made again, with the same values, when the result is reused.
+// The result itself is not worked out again, so a rule's result must only depend on its input
+// and on what its own handlers do, not on what other rules have stored in the data object.
+
+// MemoStats counts how often a cached result was reused and how often a rule had to be parsed.
+type MemoStats struct {
+	Hits   int
+	Misses int
+}
+
+// memoKey identifies a rule or combinator at a position in the token stream
+type memoKey struct {
+	id    interface{} // *ParseRule, or the *int allocated by Memo
+	index int
+}
+
+// memoEntry is a cached result, with the position it finished at
+type memoEntry struct {
+	result int
+	value  interface{}
+	err    error
+	end    int
+	calls  []handlerCall
+}
+
+// handlerCall is a ParseHandler call made while parsing a cached rule
+type handlerCall struct {
+	step  ParserRuleStep
+	value interface{}
+}
+
+// MemoStats returns the cache hits and misses on this lexer so far.
+func (l *Lexer) MemoStats() MemoStats {
+	return l.memoStats
+}
+
+// memoLookup returns the cached entry for an id at the current position
+func (l *Lexer) memoLookup(id interface{}) (*memoEntry, bool) {
+	entry, ok := l.memo[memoKey{id, l.index}]
+	if ok {
+		l.memoStats.Hits++
+	} else {
+		l.memoStats.Misses++
+	}
+	return entry, ok
+}
+
+// memoStore caches an entry for an id at the position given
+func (l *Lexer) memoStore(id interface{}, index int, entry *memoEntry) {
+	if l.memo == nil {
+		l.memo = map[memoKey]*memoEntry{}
+	}
+	entry.end = l.index
+	l.memo[memoKey{id, index}] = entry
+}
+
+// recordCall notes a ParseHandler call, if a cached rule is being parsed
+func (l *Lexer) recordCall(step ParserRuleStep, value interface{}) {
+	if l.calls != nil {
+		*l.calls = append(*l.calls, handlerCall{step, value})
+	}
+}
+
+// replayCalls makes the recorded ParseHandler calls of a cached rule again.
+// Their results were already taken into account when the rule was parsed.
+func (l *Lexer) replayCalls(calls []handlerCall, data *interface{}) {
+	for _, call := range calls {
+		l.recordCall(call.step, call.value)
+		if !l.deferCalls {
+			call.step.ParseHandler(nil, call.value, call.step.ParserType, data)
+		}
+	}
+}
+
+// newLexer creates the lexer for a parse and reads all of the input
+func (p *ParserObject) newLexer() *Lexer {
+	l := NewLexer(p.Input, p.Exclude)
+	if len(p.Punctuation) > 0 {
+		l.AddPunctuation(p.Punctuation...)
+	}
+	if p.Quotes != nil {
+		l.SetQuotes(p.Quotes...)
+	}
+	if p.NumberFormat != nil {
+		l.SetNumberFormat(*p.NumberFormat)
+	}
+	l.Tokenize()
+	return l
+}
+
+// saveMemoStats copies the cache counts from the lexer of the last parse
+func (p *ParserObject) saveMemoStats(l *Lexer) {
+	p.MemoStats = l.MemoStats()
+}
+
+// parseMemoRule is parseRule with the result cached if Memoize is set.
+func (p *ParserObject) parseMemoRule(l *Lexer, rule *ParseRule, data *interface{}) (int, error) {
+	if l.isLeftRecursive(rule) {
+		return p.parseLeftRecursive(l, rule, data)
+	}
+	if !p.Memoize {
+		return p.parseRule(l, *rule, data)
+	}
+	if entry, ok := l.memoLookup(rule); ok {
+		// Fill in the data object just as parsing the rule did
+		l.replayCalls(entry.calls, data)
+		l.Restore(entry.end)
+		return entry.result, entry.err
+	}
+	start := l.Checkpoint()
+	outer := l.calls
+	var calls []handlerCall
+	l.calls = &calls
+	result, err := p.parseRule(l, *rule, data)
+	l.calls = outer
+	if outer != nil {
+		*outer = append(*outer, calls...)
+	}
+	l.memoStore(rule, start, &memoEntry{result: result, err: err, calls: calls})
+	return result, err
+}
+
+// Memo caches the result of a combinator at each position in the token stream,
+// so that it is only run once at any position however often the grammar backtracks over it.
+// The combinator must not have side effects, since they will not happen again when its result is reused.
+func Memo(c Combinator) Combinator {
+	id := new(int)
+	return func(l *Lexer) (interface{}, error) {
+		if entry, ok := l.memoLookup(id); ok {
+			l.Restore(entry.end)
+			return entry.value, entry.err
+		}
+		start := l.Checkpoint()
+		value, err := c(l)
+		l.memoStore(id, start, &memoEntry{value: value, err: err})
+		return value, err
+	}
+}
@@ -0,0 +1,77 @@
+package ParserCore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParserObject_Memoize(t *testing.T) {
+	var calls []string
+	record := func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+		calls = append(calls, token.(string))
+		(*data).(*DataObject).TestString = token.(string)
+		return PARSE_RESULT_SUCCESS, nil
+	}
+	order := ParseRule{
+		Name: "Order",
+		Steps: []ParserRuleStep{
+			{Name: "NumShares", ParserType: PARSE_ANY_INTEGER, SkipOnError: PARSE_RESULT_SKIP_RULE},
+			{Name: "Shares", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"SHARES", "OF"},
+				Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+			{Name: "Stock", ParserType: PARSE_ANY_STRING, Options: PARSE_OPTION_CONVERT_TO_UPPERCASE,
+				SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: record},
+		},
+	}
+	when := func(name string, word string) ParseRule {
+		return ParseRule{
+			Name: name,
+			Steps: []ParserRuleStep{
+				{Name: "Order", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&order}, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "When", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{word},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: record},
+			},
+		}
+	}
+	rules := []ParseRule{when("Now", "NOW"), when("Today", "TODAY"), when("Later", "LATER")}
+
+	p := ParserObject{Input: "100 shares of Futzco later"}
+	DO := DataObject{}
+	parse, err := p.Parse(rules, &DO)
+	if parse != PARSE_RESULT_SUCCESS || p.MemoStats != (MemoStats{}) {
+		t.Errorf("Parse() failed, got %d with error '%v' and stats %+v", parse, err, p.MemoStats)
+	}
+	expected := calls
+
+	calls = nil
+	p.Memoize = true
+	memoDO := DataObject{}
+	parse, err = p.Parse(rules, &memoDO)
+	if parse != PARSE_RESULT_SUCCESS || memoDO != DO {
+		t.Errorf("Parse() failed with Memoize, expected %v, got %d %v with error '%v'", DO, parse, memoDO, err)
+	}
+	// The handlers are called again for the cached sub-rule, in the same order
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Parse() failed with Memoize, expected handler calls %v, got %v", expected, calls)
+	}
+	if p.MemoStats != (MemoStats{Hits: 2, Misses: 1}) {
+		t.Errorf("Parse() failed with Memoize, expected 2 hits and 1 miss, got %+v", p.MemoStats)
+	}
+}
+
+func TestMemo(t *testing.T) {
+	runs := 0
+	stock := Memo(Map(Seq(Expect(INTEGER), Keyword("SHARES")), func(v interface{}) (interface{}, error) {
+		runs++
+		return v, nil
+	}))
+	p := Alt(Seq(stock, Keyword("NOW")), Seq(stock, Keyword("LATER")))
+	l := NewLexer("100 SHARES LATER", nil)
+	value, err := p(l)
+	expected := []interface{}{[]interface{}{"100", "SHARES"}, "LATER"}
+	if err != nil || !reflect.DeepEqual(value, expected) {
+		t.Errorf("Memo() failed, expected %v, got '%v' with error '%v'", expected, value, err)
+	}
+	if runs != 1 || l.MemoStats() != (MemoStats{Hits: 1, Misses: 1}) {
+		t.Errorf("Memo() failed, expected one run, got %d runs and stats %+v", runs, l.MemoStats())
+	}
+}
@@ -0,0 +1,231 @@
+package ParserCore
+
+// Number words.
+// A PARSE_NUMBER_WORDS step reads a number written out in words, such as "fifty", "twenty-five",
+// "one hundred and five" or "third", as well as one written in digits, such as "50" or "3rd".
+// The words come from a NumberLanguage, so other languages can be added by filling in the tables.
+// English is used if the step does not name a language.
+
+import (
+	"strings"
+)
+
+// NumberLanguage holds the words a language uses to write numbers.
+// Words are looked up in lower case.
+type NumberLanguage struct {
+	Name            string
+	Units           map[string]int // Words for 0 to 19
+	Tens            map[string]int // Words for 20, 30 ... 90
+	Hundred         map[string]int // The word for 100, which multiplies the units before it
+	Scales          map[string]int // Words for 1000, 1000000 ..., which multiply everything before them
+	Ordinals        map[string]int // Ordinal words, such as first or twentieth, and their values
+	Joiners         []string       // Words which may join parts of a number, such as "and" in "one hundred and five"
+	OrdinalSuffixes []string       // Suffixes which make digits ordinal, such as the "rd" of "3rd"
+}
+
+// English is the default NumberLanguage.
+var English = &NumberLanguage{
+	Name: "English",
+	Units: map[string]int{
+		"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8,
+		"nine": 9, "ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15,
+		"sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+	},
+	Tens: map[string]int{
+		"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50, "sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+	},
+	Hundred: map[string]int{"hundred": 100},
+	Scales:  map[string]int{"thousand": 1000, "million": 1000000, "billion": 1000000000},
+	Ordinals: map[string]int{
+		"zeroth": 0, "first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "sixth": 6, "seventh": 7,
+		"eighth": 8, "ninth": 9, "tenth": 10, "eleventh": 11, "twelfth": 12, "thirteenth": 13, "fourteenth": 14,
+		"fifteenth": 15, "sixteenth": 16, "seventeenth": 17, "eighteenth": 18, "nineteenth": 19,
+		"twentieth": 20, "thirtieth": 30, "fortieth": 40, "fiftieth": 50, "sixtieth": 60, "seventieth": 70,
+		"eightieth": 80, "ninetieth": 90, "hundredth": 100, "thousandth": 1000, "millionth": 1000000,
+		"billionth": 1000000000,
+	},
+	Joiners:         []string{"and"},
+	OrdinalSuffixes: []string{"st", "nd", "rd", "th"},
+}
+
+// NumberWords is the value of a PARSE_NUMBER_WORDS step.
+type NumberWords struct {
+	Value   int
+	Ordinal bool   // Set for ordinals such as "third" or "3rd"
+	Text    string // The number as it was written
+}
+
+// The kinds of number word, which decide what can follow what
+const (
+	numberStart = iota
+	numberUnit
+	numberTens
+	numberHundred
+	numberScale
+	numberJoiner
+)
+
+// numberWord classifies a word, returning its kind and value and whether it is an ordinal.
+func (lang *NumberLanguage) numberWord(word string) (kind int, value int, ordinal bool, ok bool) {
+	word = strings.ToLower(word)
+	if value, ok := lang.Ordinals[word]; ok {
+		return lang.ordinalKind(value), value, true, true
+	}
+	if value, ok := lang.Units[word]; ok {
+		return numberUnit, value, false, true
+	}
+	if value, ok := lang.Tens[word]; ok {
+		return numberTens, value, false, true
+	}
+	if value, ok := lang.Hundred[word]; ok {
+		return numberHundred, value, false, true
+	}
+	if value, ok := lang.Scales[word]; ok {
+		return numberScale, value, false, true
+	}
+	for _, joiner := range lang.Joiners {
+		if strings.EqualFold(word, joiner) {
+			return numberJoiner, 0, false, true
+		}
+	}
+	return 0, 0, false, false
+}
+
+// ordinalKind works out the kind of an ordinal from its value, so "twentieth" behaves like "twenty".
+func (lang *NumberLanguage) ordinalKind(value int) int {
+	switch {
+	case value < 20:
+		return numberUnit
+	case value < 100:
+		return numberTens
+	case value == 100:
+		return numberHundred
+	}
+	return numberScale
+}
+
+// canFollow reports whether a word of one kind can follow a word of another.
+// A unit can follow tens only if it is below ten, as in twenty-five.
+// The word after a joiner must be one which could have followed the word before it.
+func canFollow(prev int, next int, value int) bool {
+	switch next {
+	case numberUnit:
+		return prev == numberStart || prev == numberHundred || prev == numberScale || prev == numberJoiner ||
+			(prev == numberTens && value > 0 && value < 10)
+	case numberTens:
+		return prev == numberStart || prev == numberHundred || prev == numberScale || prev == numberJoiner
+	case numberHundred:
+		return prev == numberUnit
+	case numberScale:
+		return prev == numberUnit || prev == numberTens || prev == numberHundred
+	case numberJoiner:
+		return prev == numberTens || prev == numberHundred || prev == numberScale
+	}
+	return false
+}
+
+// adjacent reports whether one token directly follows another, with nothing between them.
+func adjacent(first Token, second Token) bool {
+	return first.Offset+len(first.Raw) == second.Offset
+}
+
+// parseNumberWords reads a number written in words or digits.
+func parseNumberWords(l *Lexer, lang *NumberLanguage) (error, NumberWords) {
+	if lang == nil {
+		lang = English
+	}
+	start := l.Checkpoint()
+	first := l.NextToken()
+	if first.Type == INTEGER {
+		// Digits, perhaps with an ordinal suffix
+		value, err := parseInteger(first.Value)
+		if err != nil {
+			// Let parseAnyInteger describe the problem
+			l.Restore(start)
+			err, _ := parseAnyInteger(l, 0)
+			return err, NumberWords{}
+		}
+		result := NumberWords{Value: value, Text: first.Raw}
+		end := l.Checkpoint()
+		if next := l.NextToken(); next.Type == STRING && adjacent(first, next) && lang.isOrdinalSuffix(next.Value) {
+			result.Ordinal = true
+			result.Text += next.Raw
+		} else {
+			l.Restore(end)
+		}
+		return nil, result
+	}
+	l.Restore(start)
+
+	total, current := 0, 0
+	prev, joined := numberStart, numberStart // joined is the kind of word before a joiner
+	scale := 0                               // The last scale word, as each must be smaller than the one before
+	ordinal := false
+	var firstWord, lastWord Token
+	end := start // Position after the last word that belongs to the number
+	for !ordinal {
+		mark := l.Checkpoint()
+		tok := l.NextToken()
+		if prev != numberStart && tok.Type == MINUS && adjacent(lastWord, tok) {
+			// A hyphen joins two words, as in twenty-five
+			next := l.NextToken()
+			if !adjacent(tok, next) {
+				l.Restore(mark)
+				break
+			}
+			tok = next
+		}
+		if tok.Type != STRING {
+			l.Restore(mark)
+			break
+		}
+		kind, value, isOrdinal, ok := lang.numberWord(tok.Value)
+		follows := prev
+		if prev == numberJoiner {
+			follows = joined
+		}
+		if !ok || !canFollow(follows, kind, value) || (prev == numberJoiner && kind != numberUnit && kind != numberTens) ||
+			(kind == numberScale && scale != 0 && value >= scale) {
+			l.Restore(mark)
+			break
+		}
+		switch kind {
+		case numberUnit, numberTens:
+			current += value
+		case numberHundred:
+			current *= value
+		case numberScale:
+			total += current * value
+			current = 0
+			scale = value
+		}
+		if prev == numberStart {
+			firstWord = tok
+		}
+		if kind == numberJoiner {
+			joined = prev
+		}
+		prev, ordinal = kind, isOrdinal
+		if kind != numberJoiner {
+			lastWord = tok
+			end = l.Checkpoint()
+		}
+	}
+	l.Restore(end)
+	if end == start {
+		tok := l.NextToken()
+		l.Restore(start)
+		return newParseError(tok, "number"), NumberWords{}
+	}
+	return nil, NumberWords{Value: total + current, Ordinal: ordinal, Text: inputText(l, firstWord, lastWord)}
+}
+
+// isOrdinalSuffix reports whether a word is one of the language's ordinal suffixes.
+func (lang *NumberLanguage) isOrdinalSuffix(word string) bool {
+	for _, suffix := range lang.OrdinalSuffixes {
+		if strings.EqualFold(word, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package ParserCore
+
+import (
+	"testing"
+)
+
+func Test_parseNumberWords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected NumberWords
+		next     string // The text of the token left after the number
+	}{
+		{"fifty shares", NumberWords{Value: 50, Text: "fifty"}, "shares"},
+		{"twenty-five shares", NumberWords{Value: 25, Text: "twenty-five"}, "shares"},
+		{"Twenty five", NumberWords{Value: 25, Text: "Twenty five"}, ""},
+		{"one hundred and five", NumberWords{Value: 105, Text: "one hundred and five"}, ""},
+		{"two thousand three hundred", NumberWords{Value: 2300, Text: "two thousand three hundred"}, ""},
+		{"one million two hundred thousand", NumberWords{Value: 1200000, Text: "one million two hundred thousand"}, ""},
+		{"third lot", NumberWords{Value: 3, Ordinal: true, Text: "third"}, "lot"},
+		{"twenty-first", NumberWords{Value: 21, Ordinal: true, Text: "twenty-first"}, ""},
+		{"one hundredth", NumberWords{Value: 100, Ordinal: true, Text: "one hundredth"}, ""},
+		{"3rd lot", NumberWords{Value: 3, Ordinal: true, Text: "3rd"}, "lot"},
+		{"50 shares", NumberWords{Value: 50, Text: "50"}, "shares"},
+		{"1,000 shares", NumberWords{Value: 1000, Text: "1,000"}, "shares"},
+		{"five and a half", NumberWords{Value: 5, Text: "five"}, "and"},
+		{"one hundred and apples", NumberWords{Value: 100, Text: "one hundred"}, "and"},
+		{"twenty and one", NumberWords{Value: 21, Text: "twenty and one"}, ""},
+		{"twenty and ten", NumberWords{Value: 20, Text: "twenty"}, "and"},
+		{"twenty twenty", NumberWords{Value: 20, Text: "twenty"}, "twenty"},
+		{"five - six", NumberWords{Value: 5, Text: "five"}, "-"},
+		{"one thousand two million", NumberWords{Value: 1002, Text: "one thousand two"}, "million"},
+		{"first second", NumberWords{Value: 1, Ordinal: true, Text: "first"}, "second"},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseNumberWords(l, nil)
+		if err != nil || value != test.expected {
+			t.Errorf("parseNumberWords(%s) failed, expected %+v, got %+v with error '%v'", test.input, test.expected, value, err)
+			continue
+		}
+		if tok := l.NextToken(); tok.Raw != test.next {
+			t.Errorf("parseNumberWords(%s) failed, expected '%s' next, got '%s'", test.input, test.next, tok.Raw)
+		}
+	}
+
+	for _, input := range []string{"shares", "and five", "hundred", "\"five\""} {
+		l := NewLexer(input, nil)
+		err, _ := parseNumberWords(l, nil)
+		if err == nil {
+			t.Errorf("parseNumberWords(%s) failed, expected an error", input)
+		}
+		if l.Checkpoint() != 0 {
+			t.Errorf("parseNumberWords(%s) failed, expected no tokens read, got %d", input, l.Checkpoint())
+		}
+	}
+}
+
+func Test_parseNumberWords_Language(t *testing.T) {
+	french := &NumberLanguage{
+		Name:     "French",
+		Units:    map[string]int{"un": 1, "deux": 2, "trois": 3},
+		Tens:     map[string]int{"vingt": 20},
+		Hundred:  map[string]int{"cent": 100},
+		Scales:   map[string]int{"mille": 1000},
+		Ordinals: map[string]int{"premier": 1, "deuxième": 2},
+		Joiners:  []string{"et"},
+	}
+	tests := []struct {
+		input    string
+		expected NumberWords
+	}{
+		{"vingt et un", NumberWords{Value: 21, Text: "vingt et un"}},
+		{"deux cent trois", NumberWords{Value: 203, Text: "deux cent trois"}},
+		{"deuxième", NumberWords{Value: 2, Ordinal: true, Text: "deuxième"}},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseNumberWords(l, french)
+		if err != nil || value != test.expected {
+			t.Errorf("parseNumberWords(%s) failed, expected %+v, got %+v with error '%v'", test.input, test.expected, value, err)
+		}
+	}
+	l := NewLexer("fifty", nil)
+	if err, _ := parseNumberWords(l, french); err == nil {
+		t.Errorf("parseNumberWords(fifty) failed, expected an error in French")
+	}
+}
+
+func TestParserObject_NumberWords(t *testing.T) {
+	rules := []ParseRule{
+		{
+			Name: "Buy",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"BUY"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "NumShares", ParserType: PARSE_NUMBER_WORDS, SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						*(*data).(*NumberWords) = token.(NumberWords)
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+				{Name: "Shares", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"SHARES", "OF"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_FAILURE},
+				{Name: "Stock", ParserType: PARSE_ANY_STRING, SkipOnError: PARSE_RESULT_FAILURE},
+			},
+		},
+	}
+	var number NumberWords
+	p := ParserObject{Input: "buy fifty-five shares of futzco"}
+	parse, err := p.Parse(rules, &number)
+	expected := NumberWords{Value: 55, Text: "fifty-five"}
+	if parse != PARSE_RESULT_SUCCESS || number != expected {
+		t.Errorf("Parse() failed, expected %+v, got %d %+v with error '%v'", expected, parse, number, err)
+	}
+}
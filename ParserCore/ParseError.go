@@ -0,0 +1,112 @@
+package ParserCore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseError describes why the input did not match a step.
+// Use errors.As to get at it from the error returned by Parse.
+type ParseError struct {
+	Token    Token    // The token which did not match
+	Line     int      // Line of the token
+	Column   int      // Column of the token
+	Offset   int      // Byte offset of the token in the input
+	Expected []string // Token types or words that would have matched instead
+	Rule     string   // Name of the rule being parsed
+	Step     string   // Name of the step being parsed
+	Message  string   // Describes the problem when it is not simply an unexpected token
+	Err      error    // Underlying error, if any
+	After    string   // The input that was matched before the error, if any
+	Trailing []Token  // The tokens left over when the input should have ended
+}
+
+// newParseError creates a ParseError for a token which was not one of those expected.
+func newParseError(tok Token, expected ...string) *ParseError {
+	return &ParseError{
+		Token:    tok,
+		Line:     tok.Line,
+		Column:   tok.Column,
+		Offset:   tok.Offset,
+		Expected: expected,
+	}
+}
+
+// Error formats the error in the same style as the lexer positions, e.g.
+// "after 'BUY 100' expected SHARES, got FOO at line 1, column 9"
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	if e.After != "" {
+		b.WriteString("after '" + e.After + "' ")
+	}
+	b.WriteString(e.Message)
+	if len(e.Expected) > 0 {
+		if e.Message != "" {
+			b.WriteString(": ")
+		}
+		if len(e.Expected) == 1 {
+			b.WriteString("expected " + e.Expected[0])
+		} else {
+			b.WriteString("expected one of " + strings.Join(e.Expected, ", "))
+		}
+		b.WriteString(", got " + tokenText(e.Token))
+	}
+	if e.Err != nil {
+		if e.Message != "" || len(e.Expected) > 0 {
+			b.WriteString(": ")
+		}
+		b.WriteString(e.Err.Error())
+	}
+	fmt.Fprintf(&b, " at line %d, column %d", e.Line, e.Column)
+	return b.String()
+}
+
+// Unwrap returns the underlying error, if any.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// tokenText describes a token for an error message, as it was written, using its type for tokens with no text.
+func tokenText(tok Token) string {
+	if tok.Raw != "" {
+		return tok.Raw
+	}
+	if tok.Value == "" {
+		return tokenTypeName(tok.Type)
+	}
+	return tok.Value
+}
+
+// furthestError returns whichever of two errors got further into the input.
+// If both stopped at the same token, the result expects anything either of them expected,
+// the way Parsec reports errors from a choice of alternatives.
+// Errors which are not ParseErrors are ignored; the result is nil if neither is a ParseError.
+func furthestError(furthest *ParseError, err error) *ParseError {
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		return furthest
+	}
+	if furthest == nil || perr.Offset > furthest.Offset {
+		return perr
+	}
+	if perr.Offset < furthest.Offset {
+		return furthest
+	}
+	merged := *furthest
+	merged.Expected = mergeExpected(furthest.Expected, perr.Expected)
+	return &merged
+}
+
+// mergeExpected returns the expected items from both lists, without duplicates.
+func mergeExpected(a []string, b []string) []string {
+	merged := make([]string, 0, len(a)+len(b))
+	seen := map[string]bool{}
+	for _, item := range append(append([]string{}, a...), b...) {
+		if !seen[item] {
+			seen[item] = true
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,117 @@
+package ParserCore
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseError_Error(t *testing.T) {
+	tok := Token{Type: STRING, Value: "FOO", Line: 1, Column: 5, Offset: 4}
+	err := newParseError(tok, "BUY", "SELL")
+	if err.Error() != "expected one of BUY, SELL, got FOO at line 1, column 5" {
+		t.Errorf("Error() failed, got '%s'", err.Error())
+	}
+	err = newParseError(Token{Type: EOF, Line: 1, Column: 9}, "INTEGER")
+	if err.Error() != "expected INTEGER, got EOF at line 1, column 9" {
+		t.Errorf("Error() failed, got '%s'", err.Error())
+	}
+	err = newParseError(tok)
+	err.Message = "no rules matched"
+	if err.Error() != "no rules matched at line 1, column 5" {
+		t.Errorf("Error() failed, got '%s'", err.Error())
+	}
+}
+
+func TestParserObject_ParseError(t *testing.T) {
+	DO := DataObject{}
+	rules := []ParseRule{
+		{
+			Name: "BuyRule",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"BUY", "SELL"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "NumShares", ParserType: PARSE_ANY_INTEGER, SkipOnError: PARSE_RESULT_FAILURE},
+			},
+		},
+	}
+	for i := range rules[0].Steps {
+		rules[0].Steps[i].ParseHandler = func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+			return PARSE_RESULT_SUCCESS, nil
+		}
+	}
+	p := ParserObject{Input: "BUY many"}
+	_, err := p.Parse(rules, &DO)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Errorf("Parse() failed, expected a ParseError, got '%v'", err)
+		return
+	}
+	if perr.Rule != "BuyRule" || perr.Step != "NumShares" || perr.Token.Value != "many" ||
+		perr.Line != 1 || perr.Column != 5 || perr.Offset != 4 || !reflect.DeepEqual(perr.Expected, []string{"INTEGER"}) {
+		t.Errorf("Parse() failed, got ParseError %+v", *perr)
+	}
+
+	// A rule which fails outright reports what it matched too
+	expected := "after 'BUY' expected INTEGER, got many at line 1, column 5"
+	if perr.After != "BUY" || err.Error() != expected {
+		t.Errorf("Parse() failed, expected '%s', got '%v'", expected, err)
+	}
+	if _, err := p.ParseRuleSet(NewRuleSet(rules), &DO); err == nil || err.Error() != expected {
+		t.Errorf("ParseRuleSet() failed, expected '%s', got '%v'", expected, err)
+	}
+}
+
+func TestParserObject_FurthestError(t *testing.T) {
+	success := func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+		return PARSE_RESULT_SUCCESS, nil
+	}
+	rules := []ParseRule{
+		{
+			Name: "BuySell",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"BUY", "SELL"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: success},
+				{Name: "NumShares", ParserType: PARSE_ANY_INTEGER, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: success},
+				{Name: "Shares", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"SHARES"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: success},
+			},
+		},
+		{
+			Name: "DisplayStock",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"DISPLAY", "STOCK"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: success},
+			},
+		},
+		{
+			Name: "DisplayPortfolio",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"DISPLAY", "PORTFOLIO"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: success},
+			},
+		},
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"buy 100 foo", "after 'buy 100' expected SHARES, got foo at line 1, column 9"},
+		{"Display nothing", "after 'Display' expected one of STOCK, PORTFOLIO, got nothing at line 1, column 9"},
+		{"hello", "expected one of BUY, SELL, DISPLAY, got hello at line 1, column 1"},
+		{"buy", "after 'buy' expected INTEGER, got EOF at line 1, column 4"},
+	}
+	rs := NewRuleSet(rules)
+	for _, test := range tests {
+		DO := DataObject{}
+		p := ParserObject{Input: test.input}
+		_, err := p.Parse(rules, &DO)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("Parse(%s) failed, expected '%s', got '%v'", test.input, test.expected, err)
+		}
+		_, err = p.ParseRuleSet(rs, &DO)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("ParseRuleSet(%s) failed, expected '%s', got '%v'", test.input, test.expected, err)
+		}
+	}
+}
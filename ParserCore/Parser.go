@@ -1,7 +1,11 @@
 package ParserCore
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 )
 
 var ParserVersion = "1.0.0"
@@ -9,9 +13,16 @@ var ParserVersion = "1.0.0"
 // ParserObject is the main structure for parsing.
 // It contains the input string, a debug flag, and a list of tokens to exclude from parsing.
 type ParserObject struct {
-	Debug   bool // Debug flag to control debug output
-	Input   string
-	Exclude []string // List of tokens to exclude from parsing
+	Debug              bool // Debug flag to control debug output
+	Input              string
+	Exclude            []string         // List of tokens to exclude from parsing
+	AllowTrailingInput bool             // If set, a rule matches even if input is left over after its last step
+	Memoize            bool             // If set, sub-rule results are cached by position so no sub-rule is parsed twice at the same place
+	MemoStats          MemoStats        // Cache hits and misses from the last parse, if Memoize is set
+	Punctuation        []Punctuation    // Punctuation to add to the lexer's table, such as <= or ->
+	Quotes             []Quote          // Quoted string delimiters, if not DefaultQuotes
+	NumberFormat       *NumberFormat    // How numbers are read, if not DefaultNumberFormat
+	Clock              func() time.Time // The current time, for relative dates such as TOMORROW, time.Now if nil
 }
 
 // Constants
@@ -32,6 +43,24 @@ const (
 	PARSE_PLUS
 	PARSE_PERCENT
 	PARSE_EQUAL
+	PARSE_EOF
+	PARSE_SUBRULE
+	PARSE_EXPRESSION
+	PARSE_TOKEN
+	PARSE_NUMBER_WORDS
+	PARSE_MONEY
+	PARSE_PERCENTAGE
+	PARSE_QUANTITY
+	PARSE_DATE
+	PARSE_TIME
+	PARSE_DATETIME
+	PARSE_DURATION
+	PARSE_BOOLEAN
+	PARSE_MAPPED_CHOICE
+	PARSE_REGEX
+	PARSE_CUSTOM
+	PARSE_WORDS_UNTIL
+	PARSE_LIST
 )
 
 // ParserNames is a list of names for the parser types.
@@ -51,6 +80,32 @@ var ParserNames = []string{
 	"PARSE_PLUS",
 	"PARSE_PERCENT",
 	"PARSE_EQUAL",
+	"PARSE_EOF",
+	"PARSE_SUBRULE",
+	"PARSE_EXPRESSION",
+	"PARSE_TOKEN",
+	"PARSE_NUMBER_WORDS",
+	"PARSE_MONEY",
+	"PARSE_PERCENTAGE",
+	"PARSE_QUANTITY",
+	"PARSE_DATE",
+	"PARSE_TIME",
+	"PARSE_DATETIME",
+	"PARSE_DURATION",
+	"PARSE_BOOLEAN",
+	"PARSE_MAPPED_CHOICE",
+	"PARSE_REGEX",
+	"PARSE_CUSTOM",
+	"PARSE_WORDS_UNTIL",
+	"PARSE_LIST",
+}
+
+// parserName returns the name of a parser type, or a placeholder for unknown types.
+func parserName(parserType int) string {
+	if parserType < 0 || parserType >= len(ParserNames) {
+		return fmt.Sprintf("UNKNOWN(%d)", parserType)
+	}
+	return ParserNames[parserType]
 }
 
 // When we parse something, here are possible error codes.
@@ -73,11 +128,19 @@ const (
 	PARSE_OPTION_CONVERT_TO_UPPERCASE = 1 << iota
 	PARSE_OPTION_CONVERT_TO_LOWERCASE
 	PARSE_OPTION_STRING_IS_OPTIONAL // If this is set, the string is optional
+	PARSE_OPTION_OPTIONAL           // If this is set, the step may be left out and Default is used instead
 )
 
+// PARSE_REPEAT_UNLIMITED as the MaxCount of a step lets it repeat any number of times
+const PARSE_REPEAT_UNLIMITED = -1
+
 // For each of our rules, there are various steps.
 // Each step defines a name, the type of object we expect
 // any objects we need to use and functions to handle success and failure.
+//
+// A step with a non-zero MaxCount repeats: it must match at least MinCount times and
+// at most MaxCount times, or any number of times if MaxCount is PARSE_REPEAT_UNLIMITED.
+// The values of a repeated step reach the ParseHandler as a []interface{}.
 type ParserRuleStep struct {
 	Name         string
 	ParserType   int
@@ -85,111 +148,352 @@ type ParserRuleStep struct {
 	SkipOnError  int
 	ParsedValues []string
 	ParseHandler func(err error, token interface{}, tokType int, data *interface{}) (int, error)
+	Default      interface{}            // Value passed to the handler when an optional step is left out
+	MinCount     int                    // Least number of times a repeated step must match
+	MaxCount     int                    // Most number of times the step may match, 0 if it does not repeat
+	SubRules     []*ParseRule           // Rules tried in order by a PARSE_SUBRULE step
+	SubRuleSet   *RuleSet               // Rule set tried by a PARSE_SUBRULE step, after SubRules
+	Expression   *ExpressionParser      // Operators and operands of a PARSE_EXPRESSION step
+	TokenType    TokenType              // Type of token matched by a PARSE_TOKEN step
+	Language     *NumberLanguage        // Words read by a PARSE_NUMBER_WORDS step, English if nil
+	Amounts      *AmountTable           // Currencies and multipliers of PARSE_MONEY and PARSE_QUANTITY steps, DefaultAmounts if nil
+	MappedValues map[string]interface{} // Words read by a PARSE_MAPPED_CHOICE step and the values they stand for
+	Regex        *regexp.Regexp         // Expression matched by a PARSE_REGEX step, or by each word of a PARSE_WORDS_UNTIL step
+	Matcher      Combinator             // Reads the value of a PARSE_CUSTOM step
+	StopWords    []string               // Words which end a PARSE_WORDS_UNTIL step
+	StopTypes    []TokenType            // Types of token which end a PARSE_WORDS_UNTIL step
+	WordTypes    []TokenType            // Types of token besides STRING which a PARSE_WORDS_UNTIL step reads
+	Element      *ParserRuleStep        // Step which reads each item of a PARSE_LIST step
+	Conjunctions []string               // Words which join the items of a PARSE_LIST step, as well as commas, DefaultConjunctions if nil
 }
 
 // ParserRule defines a rule that consists of multiple steps.
+// Unless AllowTrailingInput is set here or on the ParserObject, a rule only matches
+// if its steps use up the whole input.
 type ParseRule struct {
-	Name  string
-	Steps []ParserRuleStep
+	Name               string
+	Steps              []ParserRuleStep
+	AllowTrailingInput bool // If set, input may be left over after the last step
+}
+
+// parseStepValue reads the value for a single step from the lexer according to its ParserType.
+// The data object is only needed by steps which run other rules.
+func (p *ParserObject) parseStepValue(l *Lexer, step ParserRuleStep, data *interface{}) (error, interface{}) {
+	debug := p.Debug
+	var err error
+	var value interface{}
+	switch step.ParserType {
+	case PARSE_ANY_STRING:
+		IfDebug(debug, fmt.Printf, "       Parsing ANY STRING\n")
+		err, value = parseAnyString(l, step.Options)
+	case PARSE_ANY_FLOAT:
+		IfDebug(debug, fmt.Printf, "       Parsing ANY FLOAT\n")
+		err, value = parseAnyFloat(l, step.Options)
+	case PARSE_ANY_INTEGER:
+		IfDebug(debug, fmt.Printf, "       Parsing ANY INTEGER\n")
+		err, value = parseAnyInteger(l, step.Options)
+	case PARSE_ANY_QUOTED_STRING:
+		IfDebug(debug, fmt.Printf, "       Parsing ANY QUOTED STRING\n")
+		err, value = parseAnyQuotedString(l, step.Options)
+	case PARSE_COMMA:
+		IfDebug(debug, fmt.Printf, "       Parsing COMMA\n")
+		err, value = parseComma(l, step.Options)
+	case PARSE_COLON:
+		IfDebug(debug, fmt.Printf, "       Parsing COLON\n")
+		err, value = parseColon(l, step.Options)
+	case PARSE_STRING_CHOICE:
+		IfDebug(debug, fmt.Printf, "       Parsing STRING CHOICE\n")
+		err, value = parseStringChoice(l, step.ParsedValues, step.Options)
+	case PARSE_STRING_LIST:
+		IfDebug(debug, fmt.Printf, "       Parsing STRING LIST\n")
+		err, value = parseStringList(l, step.ParsedValues, step.Options)
+	case PARSE_QUESTION:
+		IfDebug(debug, fmt.Printf, "       Parsing QUESTION\n")
+		err, value = parseQuestion(l, step.Options)
+	case PARSE_LESS_THAN:
+		IfDebug(debug, fmt.Printf, "       Parsing LESS THAN\n")
+		err, value = parseLessThan(l, step.Options)
+	case PARSE_GREATER_THAN:
+		IfDebug(debug, fmt.Printf, "       Parsing GREATER THAN\n")
+		err, value = parseGreaterThan(l, step.Options)
+	case PARSE_EXCLAMATION:
+		IfDebug(debug, fmt.Printf, "       Parsing EXCLAMATION\n")
+		err, value = parseExclamation(l, step.Options)
+	case PARSE_PLUS:
+		IfDebug(debug, fmt.Printf, "       Parsing PLUS\n")
+		err, value = parsePlus(l, step.Options)
+	case PARSE_PERCENT:
+		IfDebug(debug, fmt.Printf, "       Parsing PERCENT\n")
+		err, value = parsePercent(l, step.Options)
+	case PARSE_EQUAL:
+		IfDebug(debug, fmt.Printf, "       Parsing EQUAL\n")
+		err, value = parseEqual(l, step.Options)
+	case PARSE_EOF:
+		IfDebug(debug, fmt.Printf, "       Parsing EOF\n")
+		err, value = parseEOF(l, step.Options)
+	case PARSE_SUBRULE:
+		IfDebug(debug, fmt.Printf, "       Parsing SUBRULE\n")
+		err, value = p.parseSubRule(l, step, data)
+	case PARSE_EXPRESSION:
+		IfDebug(debug, fmt.Printf, "       Parsing EXPRESSION\n")
+		err, value = parseExpression(l, step.Expression)
+	case PARSE_TOKEN:
+		IfDebug(debug, fmt.Printf, "       Parsing TOKEN %s\n", tokenTypeName(step.TokenType))
+		err, value = parseToken(l, step.TokenType, step.Options)
+	case PARSE_NUMBER_WORDS:
+		IfDebug(debug, fmt.Printf, "       Parsing NUMBER WORDS\n")
+		err, value = parseNumberWords(l, step.Language)
+	case PARSE_MONEY:
+		IfDebug(debug, fmt.Printf, "       Parsing MONEY\n")
+		err, value = parseMoney(l, step.Amounts)
+	case PARSE_PERCENTAGE:
+		IfDebug(debug, fmt.Printf, "       Parsing PERCENTAGE\n")
+		err, value = parsePercentage(l)
+	case PARSE_QUANTITY:
+		IfDebug(debug, fmt.Printf, "       Parsing QUANTITY\n")
+		err, value = parseQuantity(l, step.Amounts)
+	case PARSE_DATE:
+		IfDebug(debug, fmt.Printf, "       Parsing DATE\n")
+		err, value = parseDate(l, p.now())
+	case PARSE_TIME:
+		IfDebug(debug, fmt.Printf, "       Parsing TIME\n")
+		err, value = parseTime(l, p.now())
+	case PARSE_DATETIME:
+		IfDebug(debug, fmt.Printf, "       Parsing DATETIME\n")
+		err, value = parseDateTime(l, p.now())
+	case PARSE_DURATION:
+		IfDebug(debug, fmt.Printf, "       Parsing DURATION\n")
+		err, value = parseDuration(l)
+	case PARSE_BOOLEAN:
+		IfDebug(debug, fmt.Printf, "       Parsing BOOLEAN\n")
+		err, value = parseBoolean(l)
+	case PARSE_MAPPED_CHOICE:
+		IfDebug(debug, fmt.Printf, "       Parsing MAPPED CHOICE\n")
+		err, value = parseMappedChoice(l, step.MappedValues, step.Options)
+	case PARSE_REGEX:
+		IfDebug(debug, fmt.Printf, "       Parsing REGEX %v\n", step.Regex)
+		err, value = parseRegex(l, step.Regex)
+	case PARSE_CUSTOM:
+		IfDebug(debug, fmt.Printf, "       Parsing CUSTOM\n")
+		err, value = parseCustom(l, step.Matcher)
+	case PARSE_WORDS_UNTIL:
+		IfDebug(debug, fmt.Printf, "       Parsing WORDS UNTIL %v\n", step.StopWords)
+		err, value = parseWordsUntil(l, step)
+	case PARSE_LIST:
+		IfDebug(debug, fmt.Printf, "       Parsing LIST\n")
+		err, value = p.parseList(l, step, data)
+	default:
+		IfDebug(debug, fmt.Printf, "       Unknown parser type %d\n", step.ParserType)
+		start := l.Checkpoint()
+		perr := newParseError(l.NextToken())
+		perr.Message = fmt.Sprintf("unknown parser type %d", step.ParserType)
+		l.Restore(start)
+		err = perr
+	}
+	return err, value
 }
 
 // parseRule processes a single rule with its steps.
-// It uses the Lexer to read tokens and applies the ParseHandler for each step.
+// Each step is compiled to a Combinator which reads its value from the Lexer,
+// and the ParseHandler is then applied to that value.
 // If any step fails, it returns an error including a request to skip to the next rule
-func parseRule(l *Lexer, rule ParseRule, data *interface{}, debug bool) (int, error) {
+func (p *ParserObject) parseRule(l *Lexer, rule ParseRule, data *interface{}) (int, error) {
+	debug := p.Debug
 	// For each step in the rule
 	for _, step := range rule.Steps {
 		IfDebug(debug, fmt.Printf, "%sParse: Trying step: %s for rule: %s%s\n",
 			BlueText, step.Name, rule.Name, ResetText)
-		IfDebug(debug, fmt.Printf, "       Expecting token type %s with options %d\n", ParserNames[step.ParserType], step.Options)
-		// Call the lexer to get the next token -- requesting a specific type to be decoded
+		IfDebug(debug, fmt.Printf, "       Expecting token type %s with options %d\n", parserName(step.ParserType), step.Options)
+		// Run the step's combinator to read the value the step expects.
 		// If the type is wrong return an error
 		// If the type is correct, call the ParseHandler to process the token
-		var err error
-		var value interface{}
-		switch step.ParserType {
-		case PARSE_ANY_STRING:
-			IfDebug(debug, fmt.Printf, "       Parsing ANY STRING\n")
-			err, value = parseAnyString(l, step.Options)
-		case PARSE_ANY_FLOAT:
-			IfDebug(debug, fmt.Printf, "       Parsing ANY FLOAT\n")
-			err, value = parseAnyFloat(l, step.Options)
-		case PARSE_ANY_INTEGER:
-			IfDebug(debug, fmt.Printf, "       Parsing ANY INTEGER\n")
-			err, value = parseAnyInteger(l, step.Options)
-		case PARSE_ANY_QUOTED_STRING:
-			IfDebug(debug, fmt.Printf, "       Parsing ANY QUOTED STRING\n")
-			err, value = parseAnyQuotedString(l, step.Options)
-		case PARSE_COMMA:
-			IfDebug(debug, fmt.Printf, "       Parsing COMMA\n")
-			err, value = parseComma(l, step.Options)
-		case PARSE_COLON:
-			IfDebug(debug, fmt.Printf, "       Parsing COLON\n")
-			err, value = parseColon(l, step.Options)
-		case PARSE_STRING_CHOICE:
-			IfDebug(debug, fmt.Printf, "       Parsing STRING CHOICE\n")
-			err, value = parseStringChoice(l, step.ParsedValues, step.Options)
-		case PARSE_STRING_LIST:
-			IfDebug(debug, fmt.Printf, "       Parsing STRING LIST\n")
-			err, value = parseStringList(l, step.ParsedValues, step.Options)
-		case PARSE_QUESTION:
-			IfDebug(debug, fmt.Printf, "       Parsing QUESTION\n")
-			err, value = parseQuestion(l, step.Options)
-		case PARSE_LESS_THAN:
-			IfDebug(debug, fmt.Printf, "       Parsing LESS THAN\n")
-			err, value = parseLessThan(l, step.Options)
-		case PARSE_GREATER_THAN:
-			IfDebug(debug, fmt.Printf, "       Parsing GREATER THAN\n")
-			err, value = parseGreaterThan(l, step.Options)
-		case PARSE_EXCLAMATION:
-			IfDebug(debug, fmt.Printf, "       Parsing EXCLAMATION\n")
-			err, value = parseExclamation(l, step.Options)
-		case PARSE_PLUS:
-			IfDebug(debug, fmt.Printf, "       Parsing PLUS\n")
-			err, value = parsePlus(l, step.Options)
-		case PARSE_PERCENT:
-			IfDebug(debug, fmt.Printf, "       Parsing PERCENT\n")
-			err, value = parsePercent(l, step.Options)
-		case PARSE_EQUAL:
-			IfDebug(debug, fmt.Printf, "       Parsing EQUAL\n")
-			err, value = parseEqual(l, step.Options)
-		default:
-			IfDebug(debug, fmt.Printf, "       Unknown parser type %d\n", step.ParserType)
-			return PARSE_RESULT_FAILURE, fmt.Errorf("unknown parser type %d", step.ParserType)
-		}
+		start := l.Checkpoint()
+		value, err := p.stepCombinator(step, data)(l)
 		if err != nil {
 			IfDebug(debug, fmt.Printf, "%s       Error parsing step %s: %v%s\n",
 				RedText, step.Name, err, ResetText)
+			var failure ruleFailure
+			if errors.As(err, &failure) {
+				// A sub-rule failed outright, so this rule fails too
+				return PARSE_RESULT_FAILURE, failure.err
+			}
+			var perr *ParseError
+			if errors.As(err, &perr) && perr.Rule == "" {
+				perr.Rule = rule.Name
+				perr.Step = step.Name
+			}
+			if step.ParserType < 0 || step.ParserType >= len(ParserNames) {
+				return PARSE_RESULT_FAILURE, err
+			}
+			if step.SkipOnError == PARSE_RESULT_SKIP_STEP {
+				// The step has already put back what it read, so carry on from the same place
+				continue
+			}
 			return step.SkipOnError, err
-		} else {
-			IfDebug(debug, fmt.Printf, "%s       Successfully parsed step %s: %v%s\n",
-				GreenText, step.Name, value, ResetText)
-			result, err := step.ParseHandler(err, value, step.ParserType, data)
-			IfDebug(debug, fmt.Printf, "%s       ParseHandler returned result %s: Error = %v%s\n",
-				GreenText, ResultNames[result], err, ResetText)
+		}
+		IfDebug(debug, fmt.Printf, "%s       Successfully parsed step %s: %v%s\n",
+			GreenText, step.Name, value, ResetText)
+		if step.ParseHandler == nil {
+			continue
+		}
+		// The handler decides what happens next
+		l.recordCall(step, value)
+		if l.deferCalls {
+			continue
+		}
+		result, err := step.ParseHandler(nil, value, step.ParserType, data)
+		if result < 0 || result >= len(ResultNames) {
+			err = fmt.Errorf("ParseHandler returned unknown result %d", result)
+			result = PARSE_RESULT_FAILURE
+		}
+		IfDebug(debug, fmt.Printf, "%s       ParseHandler returned result %s: Error = %v%s\n",
+			GreenText, ResultNames[result], err, ResetText)
+		switch result {
+		case PARSE_RESULT_SUCCESS:
+			continue
+		case PARSE_RESULT_SKIP_STEP:
+			// Leave the token for the next step to read
+			l.Restore(start)
+			continue
+		default:
+			l.Restore(start)
+			return result, handlerError(l, rule, step, err)
 		}
 	}
 	return PARSE_RESULT_SUCCESS, nil
 }
 
+// handlerError wraps an error returned by a ParseHandler in a ParseError
+// positioned at the first token of the step.
+func handlerError(l *Lexer, rule ParseRule, step ParserRuleStep, err error) error {
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		return err
+	}
+	start := l.Checkpoint()
+	perr = newParseError(l.NextToken())
+	l.Restore(start)
+	perr.Rule = rule.Name
+	perr.Step = step.Name
+	perr.Err = err
+	if err == nil {
+		perr.Message = "step " + step.Name + " was rejected"
+	}
+	return perr
+}
+
 // Parse processes the input string using the provided rules.
 // It initializes a Lexer with the input string and iterates through the rules.
 // For each rule, it attempts to parse the input and calls the ParseHandler for each step.
 func (p *ParserObject) Parse(rules []ParseRule, data interface{}) (int, error) {
 	IfDebug(p.Debug, fmt.Printf, "%sParser: Parsing input string: %s%s\n",
 		BlueText, p.Input, ResetText)
+	// Tokenize the input once, each rule then starts again from the first token
+	l := p.newLexer()
+	defer p.saveMemoStats(l)
+	return p.parseRules(l, rules, &data, nil, !p.AllowTrailingInput)
+}
+
+// parseRules tries each rule in turn from the current position of the lexer,
+// stopping at the first rule that succeeds or fails outright.
+// If every rule is skipped, the error describes the furthest point any rule reached,
+// starting from furthest if it is not nil.
+// If requireEOF is set, a rule which leaves input over is skipped unless it allows trailing input.
+func (p *ParserObject) parseRules(l *Lexer, rules []ParseRule, data *interface{}, furthest *ParseError, requireEOF bool) (int, error) {
+	start := l.Checkpoint()
 	for _, rule := range rules {
-		l := NewLexer(p.Input, p.Exclude)
-		result, err := parseRule(l, rule, &data, p.Debug)
+		l.Restore(start)
+		result, err := p.parseRule(l, rule, data)
+		if result == PARSE_RESULT_SUCCESS && requireEOF && !rule.AllowTrailingInput {
+			// The rule matched, but it is only a match if it used up all the input
+			if err, _ = parseEOF(l, 0); err != nil {
+				IfDebug(p.Debug, fmt.Printf, "%s       Rule %s left input over: %v%s\n",
+					RedText, rule.Name, err, ResetText)
+				err.(*ParseError).Rule = rule.Name
+				result = PARSE_RESULT_SKIP_RULE
+			}
+		}
 		switch result {
 		case PARSE_RESULT_SUCCESS:
 			return result, nil
 		case PARSE_RESULT_FAILURE:
+			if perr, ok := err.(*ParseError); ok {
+				return result, matchedBefore(l, start, perr)
+			}
 			return result, err
 		case PARSE_RESULT_SKIP_RULE:
-
+			furthest = furthestError(furthest, err)
 			continue
 		}
 	}
-	return PARSE_RESULT_FAILURE, fmt.Errorf("no rules matched")
+	if furthest == nil {
+		l.Restore(start)
+		furthest = newParseError(l.NextToken())
+		furthest.Message = "no rules matched"
+		return PARSE_RESULT_FAILURE, furthest
+	}
+	return PARSE_RESULT_FAILURE, matchedBefore(l, start, furthest)
+}
+
+// matchedBefore returns a copy of an error with After set to the input from the checkpoint to the error.
+func matchedBefore(l *Lexer, start int, perr *ParseError) *ParseError {
+	l.Restore(start)
+	first := l.NextToken()
+	result := *perr
+	if result.Offset > first.Offset {
+		result.After = strings.TrimSpace(l.input[first.Offset:result.Offset])
+	}
+	return &result
+}
+
+// ruleFailure carries the error from a sub-rule which failed outright,
+// so the rule using it fails rather than skipping.
+type ruleFailure struct {
+	err error
+}
+
+func (f ruleFailure) Error() string {
+	return f.err.Error()
+}
+
+func (f ruleFailure) Unwrap() error {
+	return f.err
+}
+
+// parseSubRule runs the rules of a PARSE_SUBRULE step against the same lexer and data object,
+// returning the name of the rule that matched.
+// The lexer is left where the matching rule finished, or where it started if none matched.
+func (p *ParserObject) parseSubRule(l *Lexer, step ParserRuleStep, data *interface{}) (error, string) {
+	var rules []*ParseRule
+	for _, rule := range step.SubRules {
+		if rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+	var furthest *ParseError
+	if step.SubRuleSet != nil {
+		candidates, skipped := step.SubRuleSet.candidatesAt(l)
+		rules = append(rules, candidates...)
+		furthest = skipped
+	}
+	start := l.Checkpoint()
+	for _, rule := range rules {
+		l.Restore(start)
+		result, err := p.parseMemoRule(l, rule, data)
+		switch result {
+		case PARSE_RESULT_SUCCESS:
+			return nil, rule.Name
+		case PARSE_RESULT_FAILURE:
+			l.Restore(start)
+			return ruleFailure{err}, ""
+		}
+		furthest = furthestError(furthest, err)
+	}
+	l.Restore(start)
+	if furthest == nil {
+		furthest = newParseError(l.NextToken())
+		furthest.Message = "no sub-rules matched"
+		l.Restore(start)
+	}
+	return furthest, ""
 }
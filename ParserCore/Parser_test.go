@@ -1,6 +1,7 @@
 package ParserCore
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -256,9 +257,10 @@ func TestParserObject_OptionalString(t *testing.T) {
 	}
 
 	p := ParserObject{
-		Debug:   true,
-		Input:   "Bob Binklestein",
-		Exclude: []string{"TEST"},
+		Debug:              true,
+		Input:              "Bob Binklestein",
+		Exclude:            []string{"TEST"},
+		AllowTrailingInput: true, // The optional string leaves Binklestein unread
 	}
 	parse, err := p.Parse(Rules, &DO)
 	if err != nil {
@@ -272,3 +274,418 @@ func TestParserObject_OptionalString(t *testing.T) {
 		fmt.Printf("====== Parse Result = %v\n", DO)
 	}
 }
+
+func BenchmarkParserObject_Parse(b *testing.B) {
+	// Many rules that fail on their last step, so every rule reads the whole input
+	rules := make([]ParseRule, 0, 200)
+	for i := 0; i < 200; i++ {
+		rules = append(rules, ParseRule{
+			Name: fmt.Sprintf("Rule%d", i),
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"BUY"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "NumShares", ParserType: PARSE_ANY_INTEGER, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "Shares", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"SHARES", "OF"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "Never", ParserType: PARSE_COLON, SkipOnError: PARSE_RESULT_SKIP_RULE},
+			},
+		})
+	}
+	for i := range rules {
+		for j := range rules[i].Steps {
+			rules[i].Steps[j].ParseHandler = func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				return PARSE_RESULT_SUCCESS, nil
+			}
+		}
+	}
+	p := ParserObject{Input: "Please buy 100 shares of Futzco", Exclude: []string{"PLEASE"}}
+	DO := DataObject{}
+	for i := 0; i < b.N; i++ {
+		_, _ = p.Parse(rules, &DO)
+	}
+}
+
+func TestParserObject_TrailingInput(t *testing.T) {
+	rules := []ParseRule{
+		{
+			Name: "DisplayPortfolio",
+			Steps: []ParserRuleStep{
+				{
+					Name:         "Command",
+					ParserType:   PARSE_STRING_LIST,
+					ParsedValues: []string{"DISPLAY", "PORTFOLIO"},
+					Options:      PARSE_OPTION_CONVERT_TO_UPPERCASE,
+					SkipOnError:  PARSE_RESULT_SKIP_RULE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						return PARSE_RESULT_SUCCESS, nil
+					},
+				},
+			},
+		},
+	}
+	DO := DataObject{}
+	p := ParserObject{Input: "DISPLAY PORTFOLIO NOW PLEASE DELETE EVERYTHING", Exclude: []string{"PLEASE"}}
+	parse, err := p.Parse(rules, &DO)
+	var perr *ParseError
+	if parse != PARSE_RESULT_FAILURE || !errors.As(err, &perr) {
+		t.Errorf("Parse() failed, expected failure for trailing input, got %d with error '%v'", parse, err)
+		return
+	}
+	if len(perr.Trailing) != 3 || perr.Trailing[0].Value != "NOW" || perr.Rule != "DisplayPortfolio" {
+		t.Errorf("Parse() failed, expected trailing tokens NOW DELETE EVERYTHING, got %+v", *perr)
+	}
+
+	p.AllowTrailingInput = true
+	if parse, err = p.Parse(rules, &DO); parse != PARSE_RESULT_SUCCESS {
+		t.Errorf("Parse() failed, expected success with AllowTrailingInput, got %d with error '%v'", parse, err)
+	}
+	p.AllowTrailingInput = false
+	rules[0].AllowTrailingInput = true
+	if parse, err = p.Parse(rules, &DO); parse != PARSE_RESULT_SUCCESS {
+		t.Errorf("Parse() failed, expected success with rule AllowTrailingInput, got %d with error '%v'", parse, err)
+	}
+
+	rules[0].AllowTrailingInput = false
+	rules[0].Steps = append(rules[0].Steps, ParserRuleStep{
+		Name:        "End",
+		ParserType:  PARSE_EOF,
+		SkipOnError: PARSE_RESULT_FAILURE,
+		ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+			return PARSE_RESULT_SUCCESS, nil
+		},
+	})
+	p.AllowTrailingInput = true
+	parse, err = p.Parse(rules, &DO)
+	if parse != PARSE_RESULT_FAILURE || !errors.As(err, &perr) || perr.Step != "End" {
+		t.Errorf("Parse() failed, expected PARSE_EOF step to fail, got %d with error '%v'", parse, err)
+	}
+}
+
+func TestParserObject_HandlerResults(t *testing.T) {
+	// A rule of BUY followed by a number of shares, whose handler result is chosen by the test
+	var numSharesResult int
+	var numSharesErr error
+	rules := []ParseRule{
+		{
+			Name: "BuyRule",
+			Steps: []ParserRuleStep{
+				{
+					Name:         "Command",
+					ParserType:   PARSE_STRING_CHOICE,
+					ParsedValues: []string{"BUY"},
+					Options:      PARSE_OPTION_CONVERT_TO_UPPERCASE,
+					SkipOnError:  PARSE_RESULT_SKIP_RULE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						do := (*data).(*DataObject)
+						do.TestString = token.(string)
+						return PARSE_RESULT_SUCCESS, nil
+					},
+				},
+				{
+					Name:        "NumShares",
+					ParserType:  PARSE_ANY_INTEGER,
+					SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						do := (*data).(*DataObject)
+						if numSharesResult == PARSE_RESULT_SUCCESS {
+							do.TestInt = token.(int)
+						}
+						return numSharesResult, numSharesErr
+					},
+				},
+				{
+					Name:        "Remainder",
+					ParserType:  PARSE_ANY_INTEGER,
+					SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						do := (*data).(*DataObject)
+						do.TestFloat = float64(token.(int))
+						return PARSE_RESULT_SUCCESS, nil
+					},
+				},
+			},
+		},
+		{
+			Name: "AnythingRule",
+			Steps: []ParserRuleStep{
+				{
+					Name:        "Word",
+					ParserType:  PARSE_ANY_STRING,
+					SkipOnError: PARSE_RESULT_SKIP_RULE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						do := (*data).(*DataObject)
+						do.TestQuote = "AnythingRule"
+						return PARSE_RESULT_SUCCESS, nil
+					},
+				},
+			},
+			AllowTrailingInput: true,
+		},
+	}
+
+	// SUCCESS consumes the token and carries on
+	numSharesResult, numSharesErr = PARSE_RESULT_SUCCESS, nil
+	DO := DataObject{}
+	p := ParserObject{Input: "BUY 100 5"}
+	parse, err := p.Parse(rules, &DO)
+	if parse != PARSE_RESULT_SUCCESS || DO.TestInt != 100 || DO.TestFloat != 5 {
+		t.Errorf("PARSE_RESULT_SUCCESS failed, got %d %v with error '%v'", parse, DO, err)
+	}
+
+	// FAILURE stops the parse with the handler's error
+	shareErr := errors.New("Share number must be 1 or greater")
+	numSharesResult, numSharesErr = PARSE_RESULT_FAILURE, shareErr
+	DO = DataObject{}
+	p.Input = "BUY 0 5"
+	parse, err = p.Parse(rules, &DO)
+	var perr *ParseError
+	if parse != PARSE_RESULT_FAILURE || !errors.Is(err, shareErr) || !errors.As(err, &perr) ||
+		perr.Step != "NumShares" || perr.Column != 5 {
+		t.Errorf("PARSE_RESULT_FAILURE failed, got %d with error '%v'", parse, err)
+	}
+	if DO.TestQuote != "" {
+		t.Errorf("PARSE_RESULT_FAILURE failed, later rules were tried: %v", DO)
+	}
+
+	// SKIP_RULE moves on to the next rule
+	numSharesResult, numSharesErr = PARSE_RESULT_SKIP_RULE, nil
+	DO = DataObject{}
+	parse, err = p.Parse(rules, &DO)
+	if parse != PARSE_RESULT_SUCCESS || DO.TestQuote != "AnythingRule" {
+		t.Errorf("PARSE_RESULT_SKIP_RULE failed, got %d %v with error '%v'", parse, DO, err)
+	}
+
+	// SKIP_STEP leaves the token for the next step
+	numSharesResult, numSharesErr = PARSE_RESULT_SKIP_STEP, nil
+	DO = DataObject{}
+	p.Input = "BUY 7"
+	parse, err = p.Parse(rules, &DO)
+	if parse != PARSE_RESULT_SUCCESS || DO.TestInt != 0 || DO.TestFloat != 7 {
+		t.Errorf("PARSE_RESULT_SKIP_STEP failed, got %d %v with error '%v'", parse, DO, err)
+	}
+}
+
+func TestParserObject_OptionalAndRepeatedSteps(t *testing.T) {
+	type Order struct {
+		NumShares int
+		Stocks    []interface{}
+	}
+	buyRule := ParseRule{
+		Name: "BuyRule",
+		Steps: []ParserRuleStep{
+			{
+				Name: "Command", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"BUY"},
+				Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE,
+			},
+			{
+				Name: "NumShares", ParserType: PARSE_ANY_INTEGER, Options: PARSE_OPTION_OPTIONAL, Default: 1,
+				SkipOnError: PARSE_RESULT_FAILURE,
+				ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+					(*data).(*Order).NumShares = token.(int)
+					return PARSE_RESULT_SUCCESS, nil
+				},
+			},
+			{
+				Name: "SharesOf", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"SHARES", "OF"},
+				Options: PARSE_OPTION_CONVERT_TO_UPPERCASE | PARSE_OPTION_OPTIONAL, SkipOnError: PARSE_RESULT_FAILURE,
+			},
+			{
+				Name: "Stocks", ParserType: PARSE_ANY_STRING, MinCount: 1, MaxCount: PARSE_REPEAT_UNLIMITED,
+				Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_FAILURE,
+				ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+					(*data).(*Order).Stocks = token.([]interface{})
+					return PARSE_RESULT_SUCCESS, nil
+				},
+			},
+		},
+	}
+	tests := []struct {
+		input     string
+		numShares int
+		stocks    []interface{}
+	}{
+		{"BUY 100 SHARES OF FUTZCO", 100, []interface{}{"FUTZCO"}},
+		{"BUY 100 FUTZCO", 100, []interface{}{"FUTZCO"}},
+		{"BUY SHARES OF FUTZCO", 1, []interface{}{"FUTZCO"}},
+		{"buy aapl msft goog", 1, []interface{}{"AAPL", "MSFT", "GOOG"}},
+	}
+	for _, test := range tests {
+		order := Order{}
+		p := ParserObject{Input: test.input}
+		parse, err := p.Parse([]ParseRule{buyRule}, &order)
+		if parse != PARSE_RESULT_SUCCESS || order.NumShares != test.numShares || fmt.Sprint(order.Stocks) != fmt.Sprint(test.stocks) {
+			t.Errorf("Parse(%s) failed, got %d %v with error '%v'", test.input, parse, order, err)
+		}
+	}
+
+	p := ParserObject{Input: "BUY 100"}
+	if parse, err := p.Parse([]ParseRule{buyRule}, &Order{}); parse != PARSE_RESULT_FAILURE {
+		t.Errorf("Parse(BUY 100) failed, expected failure for missing stock, got %d with error '%v'", parse, err)
+	}
+
+	// At most two stocks
+	buyRule.Steps[3].MaxCount = 2
+	p = ParserObject{Input: "BUY AAPL MSFT GOOG"}
+	if parse, err := p.Parse([]ParseRule{buyRule}, &Order{}); parse != PARSE_RESULT_FAILURE {
+		t.Errorf("Parse(BUY AAPL MSFT GOOG) failed, expected failure with MaxCount 2, got %d with error '%v'", parse, err)
+	}
+}
+
+func TestParserObject_SubRules(t *testing.T) {
+	setString := func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+		(*data).(*DataObject).TestString = token.(string)
+		return PARSE_RESULT_SUCCESS, nil
+	}
+	// A stock is either DISPLAY STOCK name, or a quoted name
+	stockByName := ParseRule{
+		Name: "StockByName",
+		Steps: []ParserRuleStep{
+			{Name: "Words", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"THE", "STOCK"},
+				Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+			{Name: "Name", ParserType: PARSE_ANY_STRING, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: setString},
+		},
+	}
+	stockByQuote := ParseRule{
+		Name: "StockByQuote",
+		Steps: []ParserRuleStep{
+			{Name: "Name", ParserType: PARSE_ANY_QUOTED_STRING, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: setString},
+		},
+	}
+	rejectedStock := ParseRule{
+		Name: "RejectedStock",
+		Steps: []ParserRuleStep{
+			{Name: "Name", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"SCAMCO"},
+				Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE,
+				ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+					return PARSE_RESULT_FAILURE, errors.New("SCAMCO may not be traded")
+				}},
+		},
+	}
+	rules := []ParseRule{
+		{
+			Name: "DisplayRule",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"DISPLAY"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "Stock", ParserType: PARSE_SUBRULE, SubRules: []*ParseRule{&rejectedStock, &stockByName, &stockByQuote},
+					SkipOnError: PARSE_RESULT_SKIP_RULE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*DataObject).TestQuote = token.(string)
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+			},
+		},
+		{
+			Name: "DisplayAnything",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"DISPLAY", "THE"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "Anything", ParserType: PARSE_ANY_STRING, MaxCount: PARSE_REPEAT_UNLIMITED,
+					SkipOnError: PARSE_RESULT_SKIP_RULE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*DataObject).TestQuote = "DisplayAnything"
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+			},
+		},
+	}
+	tests := []struct {
+		input  string
+		name   string
+		quote  string
+		result int
+	}{
+		{"DISPLAY THE STOCK Futzco", "Futzco", "StockByName", PARSE_RESULT_SUCCESS},
+		{"DISPLAY \"Futzco\"", "Futzco", "StockByQuote", PARSE_RESULT_SUCCESS},
+		// The sub-rule reads THE and then fails, so it must back out for the second rule
+		{"DISPLAY THE PORTFOLIO", "", "DisplayAnything", PARSE_RESULT_SUCCESS},
+		{"DISPLAY SCAMCO", "", "", PARSE_RESULT_FAILURE},
+	}
+	for _, test := range tests {
+		DO := DataObject{}
+		p := ParserObject{Input: test.input}
+		parse, err := p.Parse(rules, &DO)
+		if parse != test.result || DO.TestString != test.name || DO.TestQuote != test.quote {
+			t.Errorf("Parse(%s) failed, got %d %v with error '%v'", test.input, parse, DO, err)
+		}
+	}
+
+	// The same sub-rules from a RuleSet
+	rules[0].Steps[1].SubRules = nil
+	rules[0].Steps[1].SubRuleSet = NewRuleSet([]ParseRule{stockByName, stockByQuote})
+	DO := DataObject{}
+	p := ParserObject{Input: "DISPLAY \"Futzco\""}
+	if parse, err := p.Parse(rules, &DO); parse != PARSE_RESULT_SUCCESS || DO.TestQuote != "StockByQuote" {
+		t.Errorf("Parse() with SubRuleSet failed, got %d %v with error '%v'", parse, DO, err)
+	}
+}
+
+func TestParserObject_SkipStepOnError(t *testing.T) {
+	rules := []ParseRule{
+		{
+			Name: "SellRule",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"SELL"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				// ALL OF is left out if it isn't there, even if ALL is
+				{Name: "AllOf", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"ALL", "OF"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_STEP,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*DataObject).TestQuote = "ALL"
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+				{Name: "Stock", ParserType: PARSE_ANY_STRING, SkipOnError: PARSE_RESULT_FAILURE,
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*DataObject).TestString = token.(string)
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+			},
+		},
+	}
+	tests := []struct {
+		input string
+		quote string
+		stock string
+	}{
+		{"SELL ALL OF FUTZCO", "ALL", "FUTZCO"},
+		{"SELL ALL", "", "ALL"},
+		{"SELL FUTZCO", "", "FUTZCO"},
+	}
+	for _, test := range tests {
+		DO := DataObject{}
+		p := ParserObject{Input: test.input}
+		parse, err := p.Parse(rules, &DO)
+		if parse != PARSE_RESULT_SUCCESS || DO.TestQuote != test.quote || DO.TestString != test.stock {
+			t.Errorf("Parse(%s) failed, got %d %v with error '%v'", test.input, parse, DO, err)
+		}
+	}
+}
+
+func TestParserObject_Punctuation(t *testing.T) {
+	greaterEqual := RegisterTokenType("GREATER_EQUAL")
+	rules := []ParseRule{
+		{
+			Name: "Compare",
+			Steps: []ParserRuleStep{
+				{Name: "Left", ParserType: PARSE_ANY_STRING, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "Op", ParserType: PARSE_TOKEN, TokenType: greaterEqual, SkipOnError: PARSE_RESULT_SKIP_RULE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*DataObject).TestString = token.(string)
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+				{Name: "Right", ParserType: PARSE_ANY_INTEGER, SkipOnError: PARSE_RESULT_SKIP_RULE},
+			},
+		},
+	}
+	DO := DataObject{}
+	p := ParserObject{Input: "price >= 100", Punctuation: []Punctuation{{">=", greaterEqual}}}
+	parse, err := p.Parse(rules, &DO)
+	if parse != PARSE_RESULT_SUCCESS || DO.TestString != ">=" {
+		t.Errorf("Parse() failed, got %d %v with error '%v'", parse, DO, err)
+	}
+	p.Punctuation = nil
+	if parse, err = p.Parse(rules, &DO); parse == PARSE_RESULT_SUCCESS {
+		t.Errorf("Parse() failed, expected >= to be two tokens without the punctuation table")
+	}
+}
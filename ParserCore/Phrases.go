@@ -0,0 +1,92 @@
+package ParserCore
+
+// Phrases.
+// PARSE_ANY_STRING reads a single word, but names such as "General Motors" or "Bank of America"
+// are several.  A PARSE_WORDS_UNTIL step reads words until it reaches one of its StopWords, a token
+// of one of its StopTypes or the end of the input, and passes the handler a Phrase.
+// The stop word or token is left for the next step.
+//
+// Only STRING tokens are words, unless the step lists other types in WordTypes, so a phrase also
+// ends at punctuation, numbers and quoted strings.  If the step has a Regex, each word must match it,
+// so a phrase can end where a name does.
+
+import (
+	"strings"
+)
+
+// Phrase is the value of a PARSE_WORDS_UNTIL step.
+type Phrase struct {
+	Text   string  // The words as they were written, including the spaces and punctuation between them
+	Tokens []Token // The tokens of the words
+}
+
+// stopsPhrase reports whether a phrase stops at the next token, without reading it.
+// Stop words can be several words long, and are matched in upper case.
+func stopsPhrase(l *Lexer, tok Token, step ParserRuleStep) bool {
+	if tok.Type == EOF {
+		return true
+	}
+	for _, stopType := range step.StopTypes {
+		if tok.Type == stopType {
+			return true
+		}
+	}
+	isWord := tok.Type == STRING
+	for _, wordType := range step.WordTypes {
+		isWord = isWord || tok.Type == wordType
+	}
+	if !isWord || (step.Regex != nil && !l.anchored(step.Regex).MatchString(tok.Raw)) {
+		return true
+	}
+	for _, stop := range step.StopWords {
+		start := l.Checkpoint()
+		found := readWords(l, strings.ToUpper(stop), PARSE_OPTION_CONVERT_TO_UPPERCASE)
+		l.Restore(start)
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// phraseText returns the text of the tokens as they were written.  Where the lexer skipped an
+// ignored word between two tokens, they are separated by a single space instead.
+func phraseText(l *Lexer, tokens []Token) string {
+	var b strings.Builder
+	for i, tok := range tokens {
+		if i > 0 {
+			last := tokens[i-1]
+			gap := l.input[last.Offset+len(last.Raw) : tok.Offset]
+			if strings.TrimSpace(gap) != "" {
+				gap = " "
+			}
+			b.WriteString(gap)
+		}
+		b.WriteString(tok.Raw)
+	}
+	return b.String()
+}
+
+// parseWordsUntil reads words up to a stop word, a stop token, a token which is not a word or the
+// end of the input.  The text is converted with the step's options, as for PARSE_ANY_STRING.
+// At least one word must be read.
+func parseWordsUntil(l *Lexer, step ParserRuleStep) (error, Phrase) {
+	var phrase Phrase
+	for {
+		start := l.Checkpoint()
+		tok := l.NextToken()
+		l.Restore(start)
+		if stopsPhrase(l, tok, step) {
+			break
+		}
+		phrase.Tokens = append(phrase.Tokens, l.NextToken())
+	}
+	if len(phrase.Tokens) == 0 {
+		start := l.Checkpoint()
+		tok := l.NextToken()
+		l.Restore(start)
+		return newParseError(tok, "words"), Phrase{}
+	}
+	phrase.Text = convertString(phraseText(l, phrase.Tokens), step.Options)
+	return nil, phrase
+}
@@ -0,0 +1,94 @@
+package ParserCore
+
+import (
+	"regexp"
+	"testing"
+)
+
+func Test_parseWordsUntil(t *testing.T) {
+	at := []string{"AT"}
+	tests := []struct {
+		input    string
+		step     ParserRuleStep
+		expected string
+		count    int
+		next     string
+	}{
+		{"Bank of America AT 30", ParserRuleStep{StopWords: at}, "Bank of America", 3, "AT"},
+		{"General Motors at 30", ParserRuleStep{StopWords: at}, "General Motors", 2, "at"},
+		{"General  Motors", ParserRuleStep{StopWords: at}, "General  Motors", 2, ""},
+		{"Futzco ; DELETE 5", ParserRuleStep{StopWords: at}, "Futzco", 1, ";"},
+		{"Futzco 10 shares", ParserRuleStep{StopWords: at}, "Futzco", 1, "10"},
+		{"Coca-Cola Co. for 10", ParserRuleStep{StopWords: []string{"FOR"}, WordTypes: []TokenType{MINUS, PERIOD}},
+			"Coca-Cola Co.", 5, "for"},
+		{"Bank of America, Futzco", ParserRuleStep{StopTypes: []TokenType{COMMA}}, "Bank of America", 3, ","},
+		{"Bank of America and then", ParserRuleStep{StopWords: []string{"AND THEN"}}, "Bank of America", 3, "and"},
+		{"Bank of America and more", ParserRuleStep{StopWords: []string{"AND THEN"}}, "Bank of America and more", 5, ""},
+		{"Bank of America NOW PLEASE", ParserRuleStep{Regex: regexp.MustCompile(`[A-Z][a-z]+|of`)}, "Bank of America", 3, "NOW"},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		err, value := parseWordsUntil(l, test.step)
+		if err != nil || value.Text != test.expected || len(value.Tokens) != test.count {
+			t.Errorf("parseWordsUntil(%s) failed, expected '%s' in %d tokens, got '%s' in %d with error '%v'",
+				test.input, test.expected, test.count, value.Text, len(value.Tokens), err)
+			continue
+		}
+		if tok := l.NextToken(); tok.Raw != test.next {
+			t.Errorf("parseWordsUntil(%s) failed, expected '%s' next, got '%s'", test.input, test.next, tok.Raw)
+		}
+	}
+
+	l := NewLexer("Futzco", nil)
+	if err, value := parseWordsUntil(l, ParserRuleStep{Options: PARSE_OPTION_CONVERT_TO_UPPERCASE}); err != nil || value.Text != "FUTZCO" {
+		t.Errorf("parseWordsUntil(Futzco) failed, expected 'FUTZCO', got '%s' with error '%v'", value.Text, err)
+	}
+
+	// Ignored words are left out of the text
+	l = NewLexer("Bank PLEASE of America", []string{"PLEASE"})
+	if err, value := parseWordsUntil(l, ParserRuleStep{}); err != nil || value.Text != "Bank of America" {
+		t.Errorf("parseWordsUntil(Bank PLEASE of America) failed, expected 'Bank of America', got '%s' with error '%v'", value.Text, err)
+	}
+
+	for _, input := range []string{"at 30", "", "30 shares", "\"Futzco\""} {
+		l := NewLexer(input, nil)
+		err, _ := parseWordsUntil(l, ParserRuleStep{StopWords: at})
+		if err == nil || l.Checkpoint() != 0 {
+			t.Errorf("parseWordsUntil(%s) failed, expected an error with no tokens read, got '%v'", input, err)
+		}
+	}
+}
+
+func TestParserObject_WordsUntil(t *testing.T) {
+	type order struct {
+		stock string
+		price int
+	}
+	rules := []ParseRule{
+		{
+			Name: "Buy",
+			Steps: []ParserRuleStep{
+				{Name: "Buy", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"BUY", "SHARES", "OF"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE},
+				{Name: "Stock", ParserType: PARSE_WORDS_UNTIL, StopWords: []string{"AT"}, SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*order).stock = token.(Phrase).Text
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+				{Name: "At", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"AT"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_FAILURE},
+				{Name: "Price", ParserType: PARSE_ANY_INTEGER, SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*order).price = token.(int)
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+			},
+		},
+	}
+	var o order
+	p := ParserObject{Input: "BUY SHARES OF Bank of America AT 30"}
+	parse, err := p.Parse(rules, &o)
+	if parse != PARSE_RESULT_SUCCESS || o.stock != "Bank of America" || o.price != 30 {
+		t.Errorf("Parse() failed, expected Bank of America at 30, got %d %+v with error '%v'", parse, o, err)
+	}
+}
@@ -0,0 +1,179 @@
+package ParserCore
+
+// A RuleSet is a list of rules compiled for faster parsing.
+// Parse tries every rule in order, so the time it takes grows with the number of rules.
+// A RuleSet looks at the first step of each rule to work out which tokens the rule can
+// start with, and only tries the rules that can start with the first token of the input.
+// The rules it tries are still tried in the order they were declared, so the result is
+// the same as calling Parse with the full list.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// firstTokenTypes maps the parser types that match a single kind of token to that token type.
+var firstTokenTypes = map[int]TokenType{
+	PARSE_ANY_STRING:        STRING,
+	PARSE_ANY_INTEGER:       INTEGER,
+	PARSE_ANY_FLOAT:         FLOAT,
+	PARSE_ANY_QUOTED_STRING: QUOTED_STRING,
+	PARSE_COMMA:             COMMA,
+	PARSE_COLON:             COLON,
+	PARSE_QUESTION:          QUESTION,
+	PARSE_LESS_THAN:         LESS_THAN,
+	PARSE_GREATER_THAN:      GREATER_THAN,
+	PARSE_EXCLAMATION:       EXCLAMATION,
+	PARSE_PLUS:              PLUS,
+	PARSE_PERCENT:           PERCENT,
+	PARSE_EQUAL:             EQUAL,
+	PARSE_EOF:               EOF,
+}
+
+// RuleSet is a list of rules indexed by the first token each rule can start with.
+type RuleSet struct {
+	rules    []ParseRule
+	words    map[string][]int    // Rules starting with a given word, keyed in upper case
+	types    map[TokenType][]int // Rules starting with any token of a given type
+	anyToken []int               // Rules which could start with any token
+	expected []string            // Everything the indexed rules can start with, for error messages
+}
+
+// NewRuleSet compiles a list of rules into a RuleSet.
+func NewRuleSet(rules []ParseRule) *RuleSet {
+	rs := &RuleSet{
+		rules: rules,
+		words: map[string][]int{},
+		types: map[TokenType][]int{},
+	}
+	for i, rule := range rules {
+		words, tokType, ok := firstTokens(rule)
+		switch {
+		case !ok:
+			rs.anyToken = append(rs.anyToken, i)
+		case len(words) > 0:
+			rs.expected = mergeExpected(rs.expected, words)
+			for _, word := range words {
+				key := strings.ToUpper(word)
+				// A word listed twice in the same step should only index the rule once
+				if n := len(rs.words[key]); n == 0 || rs.words[key][n-1] != i {
+					rs.words[key] = append(rs.words[key], i)
+				}
+			}
+		default:
+			rs.expected = mergeExpected(rs.expected, []string{tokenTypeName(tokType)})
+			rs.types[tokType] = append(rs.types[tokType], i)
+		}
+	}
+	return rs
+}
+
+// firstTokens works out what the first token of a rule can be.
+// It returns either the words the rule can start with, or the type of token it starts with.
+// If the rule could start with any token, ok is false.
+func firstTokens(rule ParseRule) (words []string, tokType TokenType, ok bool) {
+	if len(rule.Steps) == 0 {
+		return nil, ERROR, false
+	}
+	step := rule.Steps[0]
+	if canSkip(step) {
+		// The first token might belong to a later step
+		return nil, ERROR, false
+	}
+	switch step.ParserType {
+	case PARSE_STRING_CHOICE:
+		if len(step.ParsedValues) == 0 {
+			return nil, ERROR, false
+		}
+		return step.ParsedValues, STRING, true
+	case PARSE_STRING_LIST:
+		if len(step.ParsedValues) == 0 {
+			return nil, ERROR, false
+		}
+		return step.ParsedValues[:1], STRING, true
+	case PARSE_TOKEN:
+		return nil, step.TokenType, true
+	case PARSE_BOOLEAN:
+		return firstWords(BooleanWords), STRING, true
+	case PARSE_MAPPED_CHOICE:
+		if len(step.MappedValues) == 0 {
+			return nil, ERROR, false
+		}
+		return firstWords(step.MappedValues), STRING, true
+	}
+	if tokType, found := firstTokenTypes[step.ParserType]; found {
+		return nil, tokType, true
+	}
+	return nil, ERROR, false
+}
+
+// Rules returns the rules in the set, in the order they were declared.
+func (rs *RuleSet) Rules() []ParseRule {
+	return rs.rules
+}
+
+// candidates returns the rules which can start with the given token, in declaration order.
+// The rules point into the set, so they can be used as memo keys.
+func (rs *RuleSet) candidates(tok Token) []*ParseRule {
+	indices := mergeIndices(rs.types[tok.Type], rs.anyToken)
+	if tok.Type == STRING {
+		indices = mergeIndices(rs.words[strings.ToUpper(tok.Value)], indices)
+	}
+	rules := make([]*ParseRule, 0, len(indices))
+	for _, i := range indices {
+		rules = append(rules, &rs.rules[i])
+	}
+	return rules
+}
+
+// mergeIndices merges two sorted lists of rule indices into one sorted list.
+func mergeIndices(a []int, b []int) []int {
+	merged := make([]int, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			merged = append(merged, a[0])
+			a = a[1:]
+		case b[0] < a[0]:
+			merged = append(merged, b[0])
+			b = b[1:]
+		default:
+			merged = append(merged, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+// ParseRuleSet is like Parse, but only tries the rules in the set that can start
+// with the first token of the input.
+func (p *ParserObject) ParseRuleSet(rs *RuleSet, data interface{}) (int, error) {
+	IfDebug(p.Debug, fmt.Printf, "%sParser: Parsing input string: %s%s\n",
+		BlueText, p.Input, ResetText)
+	l := p.newLexer()
+	defer p.saveMemoStats(l)
+	candidates, furthest := rs.candidatesAt(l)
+	IfDebug(p.Debug, fmt.Printf, "       %d of %d rules can start with the first token\n",
+		len(candidates), len(rs.rules))
+	rules := make([]ParseRule, 0, len(candidates))
+	for _, rule := range candidates {
+		rules = append(rules, *rule)
+	}
+	return p.parseRules(l, rules, &data, furthest, !p.AllowTrailingInput)
+}
+
+// candidatesAt returns the rules which can start with the next token from the lexer.
+// If any rules were left out, it also returns the error they would have failed with.
+func (rs *RuleSet) candidatesAt(l *Lexer) ([]*ParseRule, *ParseError) {
+	start := l.Checkpoint()
+	first := l.NextToken()
+	l.Restore(start)
+	rules := rs.candidates(first)
+	// The rules that were not tried would all have failed on the first token, expecting what they start with
+	var furthest *ParseError
+	if len(rules) < len(rs.rules) {
+		furthest = newParseError(first, rs.expected...)
+	}
+	return rules, furthest
+}
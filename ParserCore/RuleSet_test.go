@@ -0,0 +1,118 @@
+package ParserCore
+
+import (
+	"testing"
+)
+
+// ruleSetTestRules is a small command set whose rules record their name when they match
+func ruleSetTestRules() []ParseRule {
+	record := func(name string) func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+		return func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+			do := (*data).(*DataObject)
+			do.TestString = name
+			return PARSE_RESULT_SUCCESS, nil
+		}
+	}
+	return []ParseRule{
+		{
+			Name: "BuySell",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_CHOICE, ParsedValues: []string{"BUY", "SELL"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: record("BuySell")},
+			},
+		},
+		{
+			Name: "DisplayStock",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"DISPLAY", "STOCK"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: record("DisplayStock")},
+			},
+		},
+		{
+			Name: "Number",
+			Steps: []ParserRuleStep{
+				{Name: "Number", ParserType: PARSE_ANY_INTEGER, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: record("Number")},
+			},
+		},
+		{
+			Name: "DisplayPortfolio",
+			Steps: []ParserRuleStep{
+				{Name: "Command", ParserType: PARSE_STRING_LIST, ParsedValues: []string{"DISPLAY", "PORTFOLIO"},
+					Options: PARSE_OPTION_CONVERT_TO_UPPERCASE, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: record("DisplayPortfolio")},
+			},
+		},
+		{
+			Name: "AnyWord",
+			Steps: []ParserRuleStep{
+				{Name: "Word", ParserType: PARSE_ANY_STRING, SkipOnError: PARSE_RESULT_SKIP_RULE, ParseHandler: record("AnyWord")},
+			},
+		},
+	}
+}
+
+func TestNewRuleSet(t *testing.T) {
+	rs := NewRuleSet(ruleSetTestRules())
+	tests := []struct {
+		token    Token
+		expected []string
+	}{
+		{Token{Type: STRING, Value: "display"}, []string{"DisplayStock", "DisplayPortfolio", "AnyWord"}},
+		{Token{Type: STRING, Value: "Sell"}, []string{"BuySell", "AnyWord"}},
+		{Token{Type: INTEGER, Value: "12"}, []string{"Number"}},
+		{Token{Type: COMMA, Value: ","}, []string{}},
+	}
+	for _, test := range tests {
+		rules := rs.candidates(test.token)
+		if len(rules) != len(test.expected) {
+			t.Errorf("candidates(%s) failed, expected %v, got %d rules", test.token.Value, test.expected, len(rules))
+			continue
+		}
+		for i, rule := range rules {
+			if rule.Name != test.expected[i] {
+				t.Errorf("candidates(%s) failed, expected %v, got %s at %d", test.token.Value, test.expected, rule.Name, i)
+			}
+		}
+	}
+}
+
+func TestParserObject_ParseRuleSet(t *testing.T) {
+	rules := ruleSetTestRules()
+	rs := NewRuleSet(rules)
+	for _, input := range []string{"Display portfolio", "display stock", "buy", "42", "hello", ","} {
+		p := ParserObject{Input: input}
+		expected := DataObject{}
+		expectedResult, expectedErr := p.Parse(rules, &expected)
+		DO := DataObject{}
+		result, err := p.ParseRuleSet(rs, &DO)
+		if result != expectedResult || (err == nil) != (expectedErr == nil) || DO != expected {
+			t.Errorf("ParseRuleSet(%s) failed, expected %d %v %v, got %d %v %v",
+				input, expectedResult, expectedErr, expected, result, err, DO)
+		}
+	}
+}
+
+func TestParserObject_ParseRuleSetSkippedStep(t *testing.T) {
+	// The first step can be skipped, so the rule can start with any token
+	rules := []ParseRule{
+		{
+			Name: "MaybeNumber",
+			Steps: []ParserRuleStep{
+				{Name: "Number", ParserType: PARSE_ANY_INTEGER, SkipOnError: PARSE_RESULT_SKIP_STEP},
+				{Name: "Word", ParserType: PARSE_ANY_STRING, SkipOnError: PARSE_RESULT_FAILURE,
+					ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+						(*data).(*DataObject).TestString = token.(string)
+						return PARSE_RESULT_SUCCESS, nil
+					}},
+			},
+		},
+	}
+	rs := NewRuleSet(rules)
+	for _, input := range []string{"HELLO", "42 HELLO"} {
+		p := ParserObject{Input: input}
+		DO := DataObject{}
+		result, err := p.ParseRuleSet(rs, &DO)
+		if result != PARSE_RESULT_SUCCESS || DO.TestString != "HELLO" {
+			t.Errorf("ParseRuleSet(%s) failed, expected HELLO, got %d %v with error '%v'", input, result, DO, err)
+		}
+	}
+}
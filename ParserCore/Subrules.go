@@ -17,7 +17,8 @@
 package ParserCore
 
 import (
-	"fmt"
+	"errors"
+	"strconv"
 	"strings"
 )
 
@@ -32,72 +33,108 @@ func convertString(s string, opt int) string {
 }
 
 func parseAnyString(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == STRING {
 		value := convertString(tok.Value, opt)
 		return nil, value
 	} else {
-		return fmt.Errorf("expected STRING, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[STRING]), ""
 	}
 }
 
 func parseAnyInteger(l *Lexer, opt int) (error, int) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == INTEGER {
-		var value int
-		_, err := fmt.Sscanf(tok.Value, "%d", &value)
+		value, err := parseInteger(tok.Value)
 		if err != nil {
-			return fmt.Errorf("invalid integer value: %s at line %d, column %d", tok.Value, tok.Line, tok.Column), 0
+			perr := newParseError(tok)
+			perr.Message = "invalid integer value " + tok.Raw
+			if errors.Is(err, strconv.ErrRange) {
+				perr.Message = "integer value " + tok.Raw + " is out of range"
+			}
+			perr.Err = err
+			l.Restore(start)
+			return perr, 0
 		}
 		return nil, value
 	} else {
-		return fmt.Errorf("expected INTEGER, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), 0
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[INTEGER]), 0
 	}
 }
 
+// parseInteger converts the Value of an INTEGER token, which may have a 0x, 0o or 0b prefix.
+func parseInteger(text string) (int, error) {
+	base := 10
+	digits := strings.TrimLeft(text, "+-")
+	if len(digits) > 1 && digits[0] == '0' && prefixBase(rune(digits[1])) != 0 {
+		base = 0
+	}
+	value, err := strconv.ParseInt(text, base, strconv.IntSize)
+	return int(value), err
+}
+
 func parseAnyFloat(l *Lexer, opt int) (error, float64) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == FLOAT {
-		var value float64
-		_, err := fmt.Sscanf(tok.Value, "%f", &value)
+		value, err := strconv.ParseFloat(tok.Value, 64)
 		if err != nil {
-			return fmt.Errorf("invalid float value: %s at line %d, column %d", tok.Value, tok.Line, tok.Column), 0.0
+			perr := newParseError(tok)
+			perr.Message = "invalid float value " + tok.Raw
+			if errors.Is(err, strconv.ErrRange) {
+				perr.Message = "float value " + tok.Raw + " is out of range"
+			}
+			perr.Err = err
+			l.Restore(start)
+			return perr, 0.0
 		}
 		return nil, value
 	} else {
-		return fmt.Errorf("expected FLOAT, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), 0.0
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[FLOAT]), 0.0
 	}
 }
 
 func parseAnyQuotedString(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == QUOTED_STRING {
 		value := convertString(tok.Value, opt)
 		return nil, value
 	} else {
-		return fmt.Errorf("expected QUOTED_STRING, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[QUOTED_STRING]), ""
 	}
 }
 
 func parseComma(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == COMMA {
 		return nil, tok.Value
 	} else {
-		return fmt.Errorf("expected COMMA, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[COMMA]), ""
 	}
 }
 
 func parseColon(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == COLON {
 		return nil, tok.Value
 	} else {
-		return fmt.Errorf("expected COLON, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[COLON]), ""
 	}
 }
 
 func parseStringChoice(l *Lexer, choices []string, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == STRING {
 		value := tok.Value
@@ -107,90 +144,129 @@ func parseStringChoice(l *Lexer, choices []string, opt int) (error, string) {
 				return nil, value
 			}
 		}
-		// If we reach here, the value is not in the choices
-		if opt&PARSE_OPTION_STRING_IS_OPTIONAL != 0 {
-			l.PushBack(tok)
-			return nil, "" // Return empty string if the option is optional
-		} else {
-			return fmt.Errorf("expected one of %v, got %s at line %d, column %d", choices, tok.Value, tok.Line, tok.Column), ""
-		}
-	} else {
-		return fmt.Errorf("expected STRING, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
 	}
+	// If we reach here, the value is not in the choices
+	l.Restore(start)
+	if opt&PARSE_OPTION_STRING_IS_OPTIONAL != 0 {
+		return nil, "" // Return empty string if the option is optional
+	}
+	return newParseError(tok, choices...), ""
 }
 
+// parseStringList matches each word of the list in turn.
+// If any word does not match, the words already read are put back.
 func parseStringList(l *Lexer, sl []string, opt int) (error, []string) {
+	start := l.Checkpoint()
 	for sitem := range sl {
 		tok := l.NextToken()
-		if tok.Type == STRING {
-			value := convertString(tok.Value, opt)
-			if value != sl[sitem] {
-				return fmt.Errorf("Expected one of %v, got %s at line %d, column %d", sl, tok.Value, tok.Line, tok.Column), nil
-			}
+		if tok.Type != STRING || convertString(tok.Value, opt) != sl[sitem] {
+			l.Restore(start)
+			return newParseError(tok, sl[sitem]), nil
 		}
 	}
 	return nil, sl
 }
 
 func parseQuestion(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == QUESTION {
 		return nil, tok.Value
 	} else {
-		return fmt.Errorf("expected QUESTION, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[QUESTION]), ""
 	}
 }
 
 func parseLessThan(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == LESS_THAN {
 		return nil, tok.Value
 	} else {
-		return fmt.Errorf("expected LESS_THAN, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[LESS_THAN]), ""
 	}
 }
 
 func parseGreaterThan(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == GREATER_THAN {
 		return nil, tok.Value
 	} else {
-		return fmt.Errorf("expected GREATER_THAN, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[GREATER_THAN]), ""
 	}
 }
 
 func parseExclamation(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == EXCLAMATION {
 		return nil, tok.Value
 	} else {
-		return fmt.Errorf("expected EXCLAMATION, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[EXCLAMATION]), ""
 	}
 }
 
 func parseEqual(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == EQUAL {
 		return nil, tok.Value
 	} else {
-		return fmt.Errorf("expected EQUALS, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[EQUAL]), ""
 	}
 }
 
 func parsePlus(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == PLUS {
 		return nil, tok.Value
 	} else {
-		return fmt.Errorf("expected PLUS, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[PLUS]), ""
 	}
 }
 
 func parsePercent(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
 	tok := l.NextToken()
 	if tok.Type == PERCENT {
 		return nil, tok.Value
 	} else {
-		return fmt.Errorf("expected PERCENT, got %s at line %d, column %d", tok.Value, tok.Line, tok.Column), ""
+		l.Restore(start)
+		return newParseError(tok, TokenTypeNames[PERCENT]), ""
+	}
+}
+
+func parseEOF(l *Lexer, opt int) (error, string) {
+	start := l.Checkpoint()
+	tok := l.NextToken()
+	if tok.Type == EOF {
+		return nil, ""
+	}
+	// Collect everything that is left over so the error can report it
+	perr := newParseError(tok, TokenTypeNames[EOF])
+	for next := tok; next.Type != EOF; next = l.NextToken() {
+		perr.Trailing = append(perr.Trailing, next)
+	}
+	perr.Message = "unexpected trailing input '" + strings.TrimSpace(l.input[tok.Offset:]) + "'"
+	l.Restore(start)
+	return perr, ""
+}
+
+// parseToken matches any token of the given type, such as punctuation added with RegisterTokenType.
+func parseToken(l *Lexer, tokType TokenType, opt int) (error, string) {
+	start := l.Checkpoint()
+	tok := l.NextToken()
+	if tok.Type == tokType {
+		return nil, tok.Value
 	}
+	l.Restore(start)
+	return newParseError(tok, tokenTypeName(tokType)), ""
 }
@@ -1,6 +1,8 @@
 package ParserCore
 
 import (
+	"errors"
+	"strconv"
 	"testing"
 )
 
@@ -64,7 +66,7 @@ func Test_parseAnyFloat(t *testing.T) {
 func Test_parseAnyQuotedString(t *testing.T) {
 	l := NewLexer("\"Hello World\"", nil)
 	err, value := parseAnyQuotedString(l, PARSE_OPTION_CONVERT_TO_UPPERCASE)
-	if err != nil || value != "\"HELLO WORLD\"" {
+	if err != nil || value != "HELLO WORLD" {
 		t.Errorf("parseAnyQuotedString() failed, expected 'HELLO WORLD', got '%s' with error '%v'", value, err)
 	}
 
@@ -169,3 +171,92 @@ func Test_parseEqual(t *testing.T) {
 		t.Errorf("parseEqual() failed, expected '=', got '%s' with error '%v'", value, err)
 	}
 }
+
+func Test_parseEOF(t *testing.T) {
+	l := NewLexer("  ", nil)
+	err, _ := parseEOF(l, 0)
+	if err != nil {
+		t.Errorf("parseEOF() failed, expected EOF, got error '%v'", err)
+	}
+
+	l = NewLexer("NOW DELETE", nil)
+	err, _ = parseEOF(l, 0)
+	if err == nil || len(err.(*ParseError).Trailing) != 2 {
+		t.Errorf("parseEOF() failed, expected error with two trailing tokens, got '%v'", err)
+	}
+	if tok := l.NextToken(); tok.Value != "NOW" {
+		t.Errorf("parseEOF() consumed input on failure, next token is '%s'", tok.Value)
+	}
+}
+
+func Test_parseStringList_Backtrack(t *testing.T) {
+	l := NewLexer("DISPLAY PORTFOLIO", nil)
+	err, _ := parseStringList(l, []string{"DISPLAY", "STOCK"}, 0)
+	if err == nil {
+		t.Errorf("parseStringList() failed, expected error for DISPLAY PORTFOLIO")
+	}
+	err, value := parseStringList(l, []string{"DISPLAY", "PORTFOLIO"}, 0)
+	if err != nil {
+		t.Errorf("parseStringList() did not back out, got '%v' with error '%v'", value, err)
+	}
+}
+
+func Test_parseStringChoice_Optional(t *testing.T) {
+	l := NewLexer("123", nil)
+	err, value := parseStringChoice(l, []string{"alpha"}, PARSE_OPTION_STRING_IS_OPTIONAL)
+	if err != nil || value != "" {
+		t.Errorf("parseStringChoice() failed, expected optional choice to be skipped, got '%s' with error '%v'", value, err)
+	}
+	if tok := l.NextToken(); tok.Type != INTEGER {
+		t.Errorf("parseStringChoice() consumed a token, next token is %s", TokenTypeNames[tok.Type])
+	}
+}
+
+func Test_parseToken(t *testing.T) {
+	l := NewLexer("( )", nil)
+	err, value := parseToken(l, LPAREN, 0)
+	if err != nil || value != "(" {
+		t.Errorf("parseToken() failed, expected '(', got '%s' with error '%v'", value, err)
+	}
+	err, value = parseToken(l, LPAREN, 0)
+	if err == nil || err.Error() != "expected LPAREN, got ) at line 1, column 3" {
+		t.Errorf("parseToken() failed, expected error for ')', got '%s' with error '%v'", value, err)
+	}
+}
+
+func Test_parseAnyInteger_Formats(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"0x1F", 31},
+		{"-0x10", -16},
+		{"0o17", 15},
+		{"0b101", 5},
+		{"1,000,000", 1000000},
+		{"-3", -3},
+		{"007", 7},
+	}
+	for _, test := range tests {
+		l := NewLexer(test.input, nil)
+		if err, value := parseAnyInteger(l, 0); err != nil || value != test.expected {
+			t.Errorf("parseAnyInteger(%s) failed, expected %d, got %d with error '%v'", test.input, test.expected, value, err)
+		}
+	}
+
+	l := NewLexer("99999999999999999999", nil)
+	err, _ := parseAnyInteger(l, 0)
+	var perr *ParseError
+	if !errors.As(err, &perr) || !errors.Is(err, strconv.ErrRange) ||
+		perr.Message != "integer value 99999999999999999999 is out of range" {
+		t.Errorf("parseAnyInteger() failed, expected out of range error, got '%v'", err)
+	}
+	l = NewLexer("1e400", nil)
+	if err, _ := parseAnyFloat(l, 0); err == nil || !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("parseAnyFloat() failed, expected out of range error, got '%v'", err)
+	}
+	l = NewLexer("2.5e-3", nil)
+	if err, value := parseAnyFloat(l, 0); err != nil || value != 0.0025 {
+		t.Errorf("parseAnyFloat() failed, expected 0.0025, got %f with error '%v'", value, err)
+	}
+}
@@ -0,0 +1,120 @@
+package ParserCore
+
+// Typed rules are a generic front end to ParseRule.
+// A ParseHandler receives its data object as a *interface{} and its value as an interface{},
+// so every handler has to cast both, and a mistake only shows up as a panic at run time.
+// A Step[T, V] instead has a handler of the form func(v V, data *T) error,
+// so the compiler checks the handler against the data object and value types.
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// A Handler can return these errors to skip the rule or the step rather than failing the parse.
+var (
+	ErrSkipRule = errors.New("skip rule")
+	ErrSkipStep = errors.New("skip step")
+)
+
+// TypedStep is implemented by Step so that steps with different value types
+// can be listed together in a TypedRule.
+type TypedStep[T any] interface {
+	ruleStep() ParserRuleStep
+}
+
+// Step is a rule step for data objects of type T whose value has type V.
+// The step is described by the embedded ParserRuleStep; its ParseHandler is ignored and
+// Handler is called instead.  If Handler returns an error, the parse fails with that error,
+// unless it is ErrSkipRule or ErrSkipStep.
+//
+// V must be the type the step produces: string for strings, words and punctuation,
+// int for PARSE_ANY_INTEGER, float64 for PARSE_ANY_FLOAT, []string for PARSE_STRING_LIST,
+// NumberWords for PARSE_NUMBER_WORDS, Money for PARSE_MONEY, Percentage for PARSE_PERCENTAGE,
+// Quantity for PARSE_QUANTITY, time.Time for PARSE_DATE, PARSE_TIME and PARSE_DATETIME,
+// time.Duration for PARSE_DURATION, bool for PARSE_BOOLEAN, the type of its values for
+// PARSE_MAPPED_CHOICE, RegexMatch for PARSE_REGEX, whatever its Matcher returns for PARSE_CUSTOM,
+// Phrase for PARSE_WORDS_UNTIL and List for PARSE_LIST.
+// A repeated step produces a []interface{}.  If an optional step is left out without a Default,
+// the handler is passed the zero value of V.
+type Step[T any, V any] struct {
+	ParserRuleStep
+	Handler func(v V, data *T) error
+}
+
+// ruleStep converts the typed step into a ParserRuleStep with a ParseHandler that does the casts
+func (s Step[T, V]) ruleStep() ParserRuleStep {
+	step := s.ParserRuleStep
+	handler := s.Handler
+	step.ParseHandler = func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+		if err != nil {
+			return PARSE_RESULT_FAILURE, err
+		}
+		if handler == nil {
+			return PARSE_RESULT_SUCCESS, nil
+		}
+		do, ok := (*data).(*T)
+		if !ok {
+			return PARSE_RESULT_FAILURE, fmt.Errorf("step %s: data object is %T, expected %s",
+				step.Name, *data, reflect.TypeOf((*T)(nil)))
+		}
+		value, ok := token.(V)
+		if token == nil {
+			// An optional step left out with no Default
+			ok = true
+		}
+		if !ok {
+			return PARSE_RESULT_FAILURE, fmt.Errorf("step %s: %s produced a value of type %T, handler expects %s",
+				step.Name, parserName(step.ParserType), token, reflect.TypeOf((*V)(nil)).Elem())
+		}
+		if err := handler(value, do); err != nil {
+			switch {
+			case errors.Is(err, ErrSkipRule):
+				return PARSE_RESULT_SKIP_RULE, err
+			case errors.Is(err, ErrSkipStep):
+				return PARSE_RESULT_SKIP_STEP, nil
+			}
+			return PARSE_RESULT_FAILURE, err
+		}
+		return PARSE_RESULT_SUCCESS, nil
+	}
+	return step
+}
+
+// Bind returns a Handler which stores the value of a step in a field of the data object,
+// for steps which need do nothing more with their value:
+//
+//	Handler: ParserCore.Bind(func(o *Order) *bool { return &o.Confirmed })
+func Bind[T any, V any](field func(data *T) *V) func(v V, data *T) error {
+	return func(v V, data *T) error {
+		*field(data) = v
+		return nil
+	}
+}
+
+// TypedRule is a ParseRule whose steps all work on a data object of type T.
+type TypedRule[T any] struct {
+	Name               string
+	Steps              []TypedStep[T]
+	AllowTrailingInput bool // If set, input may be left over after the last step
+}
+
+// Untyped converts the rule into a plain ParseRule.
+func (r TypedRule[T]) Untyped() ParseRule {
+	rule := ParseRule{Name: r.Name, Steps: make([]ParserRuleStep, 0, len(r.Steps)), AllowTrailingInput: r.AllowTrailingInput}
+	for _, step := range r.Steps {
+		rule.Steps = append(rule.Steps, step.ruleStep())
+	}
+	return rule
+}
+
+// ParseTyped is the generic counterpart of ParserObject.Parse.
+// It parses the input with typed rules, filling in the data object given.
+func ParseTyped[T any](p *ParserObject, rules []TypedRule[T], data *T) (int, error) {
+	untyped := make([]ParseRule, 0, len(rules))
+	for _, rule := range rules {
+		untyped = append(untyped, rule.Untyped())
+	}
+	return p.Parse(untyped, data)
+}
@@ -0,0 +1,137 @@
+package ParserCore
+
+import (
+	"testing"
+)
+
+var typedTestRule = TypedRule[DataObject]{
+	Name: "TypedTestRule",
+	Steps: []TypedStep[DataObject]{
+		Step[DataObject, string]{
+			ParserRuleStep: ParserRuleStep{
+				Name:        "ParseString",
+				ParserType:  PARSE_ANY_STRING,
+				Options:     PARSE_OPTION_CONVERT_TO_UPPERCASE,
+				SkipOnError: PARSE_RESULT_SKIP_RULE,
+			},
+			Handler: func(v string, data *DataObject) error {
+				data.TestString = v
+				return nil
+			},
+		},
+		Step[DataObject, int]{
+			ParserRuleStep: ParserRuleStep{
+				Name:        "ParseInteger",
+				ParserType:  PARSE_ANY_INTEGER,
+				SkipOnError: PARSE_RESULT_FAILURE,
+			},
+			Handler: func(v int, data *DataObject) error {
+				data.TestInt = v
+				return nil
+			},
+		},
+		Step[DataObject, float64]{
+			ParserRuleStep: ParserRuleStep{
+				Name:        "ParseFloat",
+				ParserType:  PARSE_ANY_FLOAT,
+				SkipOnError: PARSE_RESULT_FAILURE,
+			},
+			Handler: func(v float64, data *DataObject) error {
+				data.TestFloat = v
+				return nil
+			},
+		},
+	},
+}
+
+func TestParseTyped(t *testing.T) {
+	DO := DataObject{}
+	p := ParserObject{
+		Input: "String 123 123.12",
+	}
+	parse, err := ParseTyped(&p, []TypedRule[DataObject]{typedTestRule}, &DO)
+	if err != nil || parse != PARSE_RESULT_SUCCESS {
+		t.Errorf("ParseTyped() failed, got result %d with error '%v'", parse, err)
+		return
+	}
+	if DO.TestString != "STRING" || DO.TestInt != 123 || DO.TestFloat != 123.12 {
+		t.Errorf("ParseTyped() failed, got %v", DO)
+	}
+}
+
+func TestParseTyped_AllowTrailingInput(t *testing.T) {
+	rule := typedTestRule
+	p := ParserObject{Input: "String 123 123.12 and more"}
+	DO := DataObject{}
+	if parse, err := ParseTyped(&p, []TypedRule[DataObject]{rule}, &DO); parse == PARSE_RESULT_SUCCESS {
+		t.Errorf("ParseTyped() failed, expected trailing input to be rejected, got %d with error '%v'", parse, err)
+	}
+	rule.AllowTrailingInput = true
+	if !rule.Untyped().AllowTrailingInput {
+		t.Errorf("Untyped() failed, expected AllowTrailingInput to be copied")
+	}
+	DO = DataObject{}
+	parse, err := ParseTyped(&p, []TypedRule[DataObject]{rule}, &DO)
+	if parse != PARSE_RESULT_SUCCESS || DO.TestFloat != 123.12 {
+		t.Errorf("ParseTyped() failed, expected success with rule AllowTrailingInput, got %d %v with error '%v'", parse, DO, err)
+	}
+}
+
+func TestStep_ruleStep(t *testing.T) {
+	step := Step[DataObject, string]{
+		ParserRuleStep: ParserRuleStep{Name: "WrongType", ParserType: PARSE_ANY_INTEGER},
+		Handler: func(v string, data *DataObject) error {
+			data.TestString = v
+			return nil
+		},
+	}.ruleStep()
+	var data interface{} = &DataObject{}
+	result, err := step.ParseHandler(nil, 123, PARSE_ANY_INTEGER, &data)
+	if result != PARSE_RESULT_FAILURE || err == nil {
+		t.Errorf("ruleStep() failed, expected failure for mismatched value type, got %s with error '%v'",
+			ResultNames[result], err)
+	}
+
+	data = &struct{}{}
+	result, err = step.ParseHandler(nil, "abc", PARSE_ANY_STRING, &data)
+	if result != PARSE_RESULT_FAILURE || err == nil {
+		t.Errorf("ruleStep() failed, expected failure for mismatched data type, got %s with error '%v'",
+			ResultNames[result], err)
+	}
+}
+
+func TestParseTyped_SkipErrors(t *testing.T) {
+	rule := TypedRule[DataObject]{
+		Name: "Positive",
+		Steps: []TypedStep[DataObject]{
+			Step[DataObject, int]{
+				ParserRuleStep: ParserRuleStep{Name: "Number", ParserType: PARSE_ANY_INTEGER},
+				Handler: func(v int, data *DataObject) error {
+					if v <= 0 {
+						return ErrSkipRule
+					}
+					data.TestInt = v
+					return nil
+				},
+			},
+		},
+	}
+	fallback := TypedRule[DataObject]{
+		Name: "Fallback",
+		Steps: []TypedStep[DataObject]{
+			Step[DataObject, int]{
+				ParserRuleStep: ParserRuleStep{Name: "Number", ParserType: PARSE_ANY_INTEGER},
+				Handler: func(v int, data *DataObject) error {
+					data.TestString = "Fallback"
+					return nil
+				},
+			},
+		},
+	}
+	DO := DataObject{}
+	p := ParserObject{Input: "-5"}
+	parse, err := ParseTyped(&p, []TypedRule[DataObject]{rule, fallback}, &DO)
+	if parse != PARSE_RESULT_SUCCESS || DO.TestString != "Fallback" || DO.TestInt != 0 {
+		t.Errorf("ParseTyped() failed, expected the fallback rule to match, got %d %v with error '%v'", parse, DO, err)
+	}
+}
@@ -2,30 +2,181 @@ package Rulebase
 
 import (
 	"errors"
+	"fmt"
 	"github.com/jantypas/ParserCombinatorGo/ParserCore"
+	"regexp"
 )
 
 // For our stock example, we store the decoded data here
 // DataObject is a struct that holds the parsed data from the commands
 type DataObject struct {
-	Command   string `json:"command"`   // The command to execute, e.g., "MOVE" or "WHAT IS AT"
-	NumShares int    `json:"numShares"` // The number of shares to buy or sell
-	StockName string `json:"stockName"` // The name of the stock, e.g., "Futzco"
+	Command   string   `json:"command"`   // The command to execute, e.g., "MOVE" or "WHAT IS AT"
+	NumShares int      `json:"numShares"` // The number of shares to buy or sell
+	StockName string   `json:"stockName"` // The name of the stock, e.g., "Futzco"
+	Price     float64  `json:"price"`     // The price to buy or sell at, e.g., 30, or 0 for the market price
+	Currency  string   `json:"currency"`  // The currency of the price, e.g., "USD", if one was given
+	Condition string   `json:"condition"` // The condition of an alert, e.g., "(AAPL > (150 + (5 %)))"
+	WatchList []string `json:"watchList"` // The stocks to watch, e.g., ["AAPL", "Bank of America"]
+}
+
+// These rules decode the ways a stock can be referred to, and are used as a sub-rule
+// by the rules below.  A stock can be given as
+// "Futzco Inc" - a quoted name
+// THE STOCK - the stock already in the data object, from an earlier command
+// Bank of America - a company name, whose words are capitalised apart from small words such as "of"
+// AAPL or Futzco - a ticker, or a name of one word
+var QuotedStockRule = ParserCore.ParseRule{
+	Name: "QuotedStockRule",
+	Steps: []ParserCore.ParserRuleStep{
+		{
+			Name:        "StockName",
+			ParserType:  ParserCore.PARSE_ANY_QUOTED_STRING,
+			SkipOnError: ParserCore.PARSE_RESULT_SKIP_RULE,
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				do := (*data).(*DataObject)
+				do.StockName = token.(string)
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+	},
+}
+
+var TheStockRule = ParserCore.ParseRule{
+	Name: "TheStockRule",
+	Steps: []ParserCore.ParserRuleStep{
+		{
+			Name:         "TheStock",
+			ParserType:   ParserCore.PARSE_STRING_LIST,
+			ParsedValues: []string{"THE", "STOCK"},
+			Options:      ParserCore.PARSE_OPTION_CONVERT_TO_UPPERCASE,
+			SkipOnError:  ParserCore.PARSE_RESULT_SKIP_RULE,
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				do := (*data).(*DataObject)
+				if do.StockName == "" {
+					return ParserCore.PARSE_RESULT_FAILURE, errors.New("No stock has been mentioned yet")
+				}
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+	},
+}
+
+var CompanyNameRule = ParserCore.ParseRule{
+	Name: "CompanyNameRule",
+	Steps: []ParserCore.ParserRuleStep{
+		{
+			// The words of the name, up to a price or the first word which can't be part of a name
+			Name:        "StockName",
+			ParserType:  ParserCore.PARSE_WORDS_UNTIL,
+			Regex:       regexp.MustCompile(`[A-Z][a-z][A-Za-z']*|of|and|the`),
+			StopWords:   []string{"AT"},
+			SkipOnError: ParserCore.PARSE_RESULT_SKIP_RULE,
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				do := (*data).(*DataObject)
+				do.StockName = token.(ParserCore.Phrase).Text
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+	},
+}
+
+var TickerRule = ParserCore.ParseRule{
+	Name: "TickerRule",
+	Steps: []ParserCore.ParserRuleStep{
+		{
+			Name:        "StockName",
+			ParserType:  ParserCore.PARSE_ANY_STRING,
+			SkipOnError: ParserCore.PARSE_RESULT_SKIP_RULE,
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				do := (*data).(*DataObject)
+				do.StockName = token.(string)
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+	},
+}
+
+var StockReferenceRules = []*ParserCore.ParseRule{
+	&QuotedStockRule,
+	&TheStockRule,
+	&CompanyNameRule,
+	&TickerRule,
+}
+
+// These rules decode the price of an order, and are used as a sub-rule by BuySellStockRule.
+// A price can be given as
+// AT $30 or AT 30 USD - an amount of money
+// AT 30 - a plain number
+var MoneyPriceRule = ParserCore.ParseRule{
+	Name: "MoneyPriceRule",
+	Steps: []ParserCore.ParserRuleStep{
+		{
+			Name:         "At",
+			ParserType:   ParserCore.PARSE_STRING_CHOICE,
+			ParsedValues: []string{"AT"},
+			Options:      ParserCore.PARSE_OPTION_CONVERT_TO_UPPERCASE,
+			SkipOnError:  ParserCore.PARSE_RESULT_SKIP_RULE,
+		},
+		{
+			Name:        "Price",
+			ParserType:  ParserCore.PARSE_MONEY,
+			SkipOnError: ParserCore.PARSE_RESULT_SKIP_RULE, // It may be a plain number
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				do := (*data).(*DataObject)
+				money := token.(ParserCore.Money)
+				do.Price = money.Amount
+				do.Currency = money.Currency
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+	},
+}
+
+var PlainPriceRule = ParserCore.ParseRule{
+	Name: "PlainPriceRule",
+	Steps: []ParserCore.ParserRuleStep{
+		{
+			Name:         "At",
+			ParserType:   ParserCore.PARSE_STRING_CHOICE,
+			ParsedValues: []string{"AT"},
+			Options:      ParserCore.PARSE_OPTION_CONVERT_TO_UPPERCASE,
+			SkipOnError:  ParserCore.PARSE_RESULT_SKIP_RULE,
+		},
+		{
+			Name:        "Price",
+			ParserType:  ParserCore.PARSE_QUANTITY,
+			SkipOnError: ParserCore.PARSE_RESULT_FAILURE, // AT must be followed by a price
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				do := (*data).(*DataObject)
+				do.Price = token.(ParserCore.Quantity).Value
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+	},
+}
+
+var PriceRules = []*ParserCore.ParseRule{
+	&MoneyPriceRule,
+	&PlainPriceRule,
 }
 
 // This rule decodes phrases such as
 // "BUY 100 SHARES" OF Futzco"
 // "SELL 50 SHARES" OF Futzco"
+// "BUY 10 SHARES OF Bank of America AT 30"
 var BuySellStockRule = ParserCore.ParseRule{
 	Name: "BuySellStockRule",
 	Steps: []ParserCore.ParserRuleStep{
 		{
-			// Look for BUY or SELL, if we don't find it,skip to the next rule
-			Name:         "Command",
-			ParserType:   ParserCore.PARSE_STRING_CHOICE,
-			ParsedValues: []string{"BUY", "SELL"},
-			Options:      ParserCore.PARSE_OPTION_CONVERT_TO_UPPERCASE,
-			SkipOnError:  ParserCore.PARSE_RESULT_SKIP_RULE, // If we don't find this, skip to the next rule
+			// Look for BUY or SELL, or a word meaning the same, if we don't find it,skip to the next rule
+			Name:       "Command",
+			ParserType: ParserCore.PARSE_MAPPED_CHOICE,
+			MappedValues: ParserCore.Mapped(map[string]string{
+				"BUY": "BUY", "PURCHASE": "BUY", "ACQUIRE": "BUY",
+				"SELL": "SELL", "DUMP": "SELL",
+			}),
+			Options:     ParserCore.PARSE_OPTION_CONVERT_TO_UPPERCASE,
+			SkipOnError: ParserCore.PARSE_RESULT_SKIP_RULE, // If we don't find this, skip to the next rule
 			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
 				do := (*data).(*DataObject)
 				do.Command = token.(string)
@@ -33,16 +184,21 @@ var BuySellStockRule = ParserCore.ParseRule{
 			},
 		},
 		{
-			// Look for an integer number of shares
+			// Look for the number of shares, in digits or words
 			Name:        "NumShares",
-			ParserType:  ParserCore.PARSE_ANY_INTEGER,
+			ParserType:  ParserCore.PARSE_NUMBER_WORDS,   // 50, or fifty
 			SkipOnError: ParserCore.PARSE_RESULT_FAILURE, // If we fail, this rule fails
 			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
 				dp := (*data).(*DataObject)
 				if err != nil {
 					return ParserCore.PARSE_RESULT_FAILURE, err
 				}
-				value := token.(int)
+				number := token.(ParserCore.NumberWords)
+				if number.Ordinal {
+					return ParserCore.PARSE_RESULT_FAILURE,
+						errors.New("Share number must be a count, not " + number.Text)
+				}
+				value := number.Value
 				if value <= 0 {
 					return ParserCore.PARSE_RESULT_FAILURE,
 						errors.New("Share number must be 1 or greater")
@@ -67,16 +223,25 @@ var BuySellStockRule = ParserCore.ParseRule{
 			},
 		},
 		{
-			// Look for the stock name, which is a string
+			// Look for a reference to a stock
 			Name:        "StockName",
-			ParserType:  ParserCore.PARSE_ANY_STRING,
+			ParserType:  ParserCore.PARSE_SUBRULE,
+			SubRules:    StockReferenceRules,
 			SkipOnError: ParserCore.PARSE_RESULT_FAILURE, // If we fail, this rule fails
 			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
-				if err != nil {
-					return ParserCore.PARSE_RESULT_FAILURE, err
-				}
-				do := (*data).(*DataObject)
-				do.StockName = token.(string)
+				// The sub-rule has already stored the stock name
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+		{
+			// Look for the price, if there is one
+			Name:        "Price",
+			ParserType:  ParserCore.PARSE_SUBRULE,
+			SubRules:    PriceRules,
+			Options:     ParserCore.PARSE_OPTION_OPTIONAL,
+			SkipOnError: ParserCore.PARSE_RESULT_FAILURE, // If we fail, this rule fails
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				// The sub-rule has already stored the price
 				return ParserCore.PARSE_RESULT_SUCCESS, nil
 			},
 		},
@@ -105,16 +270,13 @@ var DisplayStockRule = ParserCore.ParseRule{
 			},
 		},
 		{
-			// Look for the stock name, which is a string
+			// Look for a reference to a stock
 			Name:        "StockName",
-			ParserType:  ParserCore.PARSE_ANY_STRING,
+			ParserType:  ParserCore.PARSE_SUBRULE,
+			SubRules:    StockReferenceRules,
 			SkipOnError: ParserCore.PARSE_RESULT_FAILURE, // If we fail, this rule fails
 			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
-				if err != nil {
-					return ParserCore.PARSE_RESULT_FAILURE, err
-				}
-				do := (*data).(*DataObject)
-				do.StockName = token.(string)
+				// The sub-rule has already stored the stock name
 				return ParserCore.PARSE_RESULT_SUCCESS, nil
 			},
 		},
@@ -166,9 +328,100 @@ var LoquiddateRule = ParserCore.ParseRule{
 	},
 }
 
+// AlertConditions are the operators allowed in the condition of an alert, loosest first
+var AlertConditions = &ParserCore.ExpressionParser{
+	Operators: []ParserCore.Operator{
+		{Symbol: "OR", Kind: ParserCore.OPERATOR_INFIX, Precedence: 1},
+		{Symbol: "AND", Kind: ParserCore.OPERATOR_INFIX, Precedence: 2},
+		{Symbol: "NOT", Kind: ParserCore.OPERATOR_PREFIX, Precedence: 3},
+		{Symbol: "<", Kind: ParserCore.OPERATOR_INFIX, Precedence: 4},
+		{Symbol: ">", Kind: ParserCore.OPERATOR_INFIX, Precedence: 4},
+		{Symbol: "=", Kind: ParserCore.OPERATOR_INFIX, Precedence: 4},
+		{Symbol: "+", Kind: ParserCore.OPERATOR_INFIX, Precedence: 5},
+		{Symbol: "-", Kind: ParserCore.OPERATOR_INFIX, Precedence: 5},
+		{Symbol: "%", Kind: ParserCore.OPERATOR_POSTFIX, Precedence: 6},
+	},
+}
+
+// Alert rule
+// Takes the form ALERT WHEN condition, such as ALERT WHEN AAPL > 150 + 5%
+var AlertRule = ParserCore.ParseRule{
+	Name: "AlertRule",
+	Steps: []ParserCore.ParserRuleStep{
+		{
+			Name:         "Command",
+			ParserType:   ParserCore.PARSE_STRING_LIST,
+			ParsedValues: []string{"ALERT", "WHEN"},
+			Options:      ParserCore.PARSE_OPTION_CONVERT_TO_UPPERCASE,
+			SkipOnError:  ParserCore.PARSE_RESULT_SKIP_RULE, // If we don't find this, skip to the next rule
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				do := (*data).(*DataObject)
+				do.Command = "ALERT"
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+		{
+			Name:        "Condition",
+			ParserType:  ParserCore.PARSE_EXPRESSION,
+			Expression:  AlertConditions,
+			SkipOnError: ParserCore.PARSE_RESULT_FAILURE, // If we fail, this rule fails
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				do := (*data).(*DataObject)
+				do.Condition = fmt.Sprint(token)
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+	},
+}
+
+// Watch rule
+// Takes the form WATCH AAPL, MSFT and Bank of America
+var WatchRule = ParserCore.ParseRule{
+	Name: "Watch",
+	Steps: []ParserCore.ParserRuleStep{
+		{
+			Name:         "Command",
+			ParserType:   ParserCore.PARSE_STRING_CHOICE,
+			ParsedValues: []string{"WATCH"},
+			Options:      ParserCore.PARSE_OPTION_CONVERT_TO_UPPERCASE,
+			SkipOnError:  ParserCore.PARSE_RESULT_SKIP_RULE, // If we don't find this, skip to the next rule
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				do := (*data).(*DataObject)
+				do.Command = "WATCH"
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+		{
+			// A list of stocks, each of which can be several words
+			Name:       "Stocks",
+			ParserType: ParserCore.PARSE_LIST,
+			Element: &ParserCore.ParserRuleStep{
+				Name:       "Stock",
+				ParserType: ParserCore.PARSE_WORDS_UNTIL,
+				StopWords:  []string{"AND", "OR"},
+				StopTypes:  []ParserCore.TokenType{ParserCore.COMMA},
+			},
+			SkipOnError: ParserCore.PARSE_RESULT_FAILURE, // If we fail, this rule fails
+			ParseHandler: func(err error, token interface{}, tokType int, data *interface{}) (int, error) {
+				do := (*data).(*DataObject)
+				list := token.(ParserCore.List)
+				if list.Conjunction == "OR" {
+					return ParserCore.PARSE_RESULT_FAILURE, errors.New("Watch every stock in the list, not one OR another")
+				}
+				for _, item := range list.Items {
+					do.WatchList = append(do.WatchList, item.(ParserCore.Phrase).Text)
+				}
+				return ParserCore.PARSE_RESULT_SUCCESS, nil
+			},
+		},
+	},
+}
+
 var RuleSet = []ParserCore.ParseRule{
 	BuySellStockRule,
 	DisplayStockRule,
 	DisplayPortfolioRule,
 	LoquiddateRule,
+	AlertRule,
+	WatchRule,
 }